@@ -0,0 +1,267 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/lib/pq"
+)
+
+// Sessions of a closure
+const (
+	Morning   = "Morning"
+	Afternoon = "Afternoon"
+	FullDay   = "Full Day"
+)
+
+// settlementDays for T+2 settlement
+const settlementDays = 2
+
+// Closure is an unscheduled closure, e.g. typhoon signal no. 8 or black rainstorm
+type Closure struct {
+	Date    string
+	Session string // Morning, Afternoon or Full Day
+	Reason  string // e.g. Typhoon Signal No. 8
+}
+
+// closures are the recorded closures by date
+var closures = struct {
+	sync.Mutex
+	byDate map[string]Closure
+}{byDate: make(map[string]Closure)}
+
+// loaded marks the closures loaded from the market_closure table
+var loaded struct {
+	sync.Mutex
+	ok bool
+}
+
+// uncovered are the years outside the holidays which have been warned about
+var uncovered sync.Map
+
+// Covered checks if the holidays of the date's year are known, in YYYY-MM-DD format.
+// Only the weekends and the recorded closures are checked outside the covered years.
+func Covered(date string) bool {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	return d.Year() >= firstYear && d.Year() <= lastYear
+}
+
+// IsTradingDay checks if the market is open on the date, in YYYY-MM-DD format.
+// Only the closures recorded in memory are checked, see LoadClosuresOnceContext.
+func IsTradingDay(date string) bool {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	return isTradingDay(d)
+}
+
+// IsHalfDay checks if the date is a half trading day, i.e. Christmas Eve, New Year's Eve and Lunar New Year's Eve
+func IsHalfDay(date string) bool {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil || !isTradingDay(d) {
+		return false
+	}
+	md := d.Format("01-02")
+	return md == "12-24" || md == "12-31" || lunarNewYearEves[date]
+}
+
+// Holiday gets the name of the public holiday on the date, empty if none
+func Holiday(date string) string {
+	return holidays[date]
+}
+
+// NextTradingDay gets the first trading day after the date
+func NextTradingDay(date string) (string, error) {
+	return addTradingDays(date, 1)
+}
+
+// PrevTradingDay gets the last trading day before the date
+func PrevTradingDay(date string) (string, error) {
+	return addTradingDays(date, -1)
+}
+
+// SettlementDate gets the T+2 settlement date of a trade on the date
+func SettlementDate(date string) (string, error) {
+	if !IsTradingDay(date) {
+		return "", fmt.Errorf("not a trading day - %s", date)
+	}
+	return addTradingDays(date, settlementDays)
+}
+
+// RecordClosure records an unscheduled closure, only full day closures are non trading days
+func RecordClosure(c Closure) error {
+	if _, err := time.Parse("2006-01-02", c.Date); err != nil {
+		return err
+	}
+	if c.Session != Morning && c.Session != Afternoon && c.Session != FullDay {
+		return fmt.Errorf("invalid session - %s", c.Session)
+	}
+	closures.Lock()
+	closures.byDate[c.Date] = c
+	closures.Unlock()
+	return nil
+}
+
+// GetClosure gets the recorded closure on the date
+func GetClosure(date string) (Closure, bool) {
+	closures.Lock()
+	defer closures.Unlock()
+	c, ok := closures.byDate[date]
+	return c, ok
+}
+
+// LoadClosures loads the closures from the market_closure table
+func LoadClosures() error {
+	return LoadClosuresContext(context.Background())
+}
+
+// LoadClosuresContext loads the closures from the market_closure table
+func LoadClosuresContext(ctx context.Context) error {
+	database, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	query := `
+    SELECT
+       date, session, reason
+    FROM
+       market_closure
+    ORDER BY
+       date;
+    `
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c    Closure
+			date time.Time
+		)
+		if err := rows.Scan(&date, &c.Session, &c.Reason); err != nil {
+			return err
+		}
+		c.Date = date.Format("2006-01-02")
+		if err := RecordClosure(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LoadClosuresOnceContext loads the closures from the market_closure table unless loaded already,
+// a failed load is retried on the next call
+func LoadClosuresOnceContext(ctx context.Context) error {
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	if loaded.ok {
+		return nil
+	}
+	if err := LoadClosuresContext(ctx); err != nil {
+		return err
+	}
+	loaded.ok = true
+	return nil
+}
+
+// InsertClosure inserts to the market_closure table
+func InsertClosure(data []Closure) error {
+	return InsertClosureContext(context.Background(), data)
+}
+
+// InsertClosureContext inserts to the market_closure table
+func InsertClosureContext(ctx context.Context, data []Closure) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("market_closure", "date", "session", "reason"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Session, model.Reason)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// isTradingDay checks the weekends, holidays and full day closures
+func isTradingDay(d time.Time) bool {
+	if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		return false
+	}
+	date := d.Format("2006-01-02")
+	if d.Year() < firstYear || d.Year() > lastYear {
+		if _, warned := uncovered.LoadOrStore(d.Year(), true); !warned {
+			log.Printf("Warning: holidays of %d are not covered, only weekends and closures are checked", d.Year())
+		}
+	}
+	if _, ok := holidays[date]; ok {
+		return false
+	}
+	if c, ok := GetClosure(date); ok && c.Session == FullDay {
+		return false
+	}
+	return true
+}
+
+// addTradingDays moves n trading days from the date, backward if n is negative
+func addTradingDays(date string, n int) (string, error) {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", err
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		d = d.AddDate(0, 0, step)
+		if isTradingDay(d) {
+			n--
+		}
+	}
+	return d.Format("2006-01-02"), nil
+}
@@ -0,0 +1,147 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsTradingDay(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		want bool
+	}{
+		{"weekday", "2021-02-26", true},
+		{"weekend", "2021-02-27", false},
+		{"lunar new year", "2021-02-12", false},
+		{"christmas", "2021-12-27", false},
+		{"new year 2027", "2027-01-01", false},
+		{"lunar new year 2027", "2027-02-09", false},
+		{"half day", "2021-02-11", true},
+		{"invalid", "2021-02-30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTradingDay(tt.date); got != tt.want {
+				t.Errorf("IsTradingDay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHalfDay(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		want bool
+	}{
+		{"lunar new year eve", "2021-02-11", true},
+		{"christmas eve", "2021-12-24", true},
+		{"new year eve on weekend", "2022-12-31", false},
+		{"lunar new year eve on weekend", "2023-01-21", false},
+		{"friday before lunar new year eve on weekend", "2023-01-20", false},
+		{"normal day", "2021-02-26", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHalfDay(tt.date); got != tt.want {
+				t.Errorf("IsHalfDay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCovered(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		want bool
+	}{
+		{"first year", "2020-01-02", true},
+		{"last year", "2027-12-31", true},
+		{"before", "2019-12-31", false},
+		{"after", "2028-01-03", false},
+		{"invalid", "2021-02-30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Covered(tt.date); got != tt.want {
+				t.Errorf("Covered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettlementDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{"normal", "2021-02-23", "2021-02-25", false},
+		{"over weekend", "2021-02-25", "2021-03-01", false},
+		{"over lunar new year", "2021-02-10", "2021-02-16", false},
+		{"holiday", "2021-02-12", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SettlementDate(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SettlementDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SettlementDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// restoreClosures restores the recorded closures after the test
+func restoreClosures(t *testing.T) {
+	closures.Lock()
+	saved := make(map[string]Closure, len(closures.byDate))
+	for k, v := range closures.byDate {
+		saved[k] = v
+	}
+	closures.Unlock()
+
+	t.Cleanup(func() {
+		closures.Lock()
+		closures.byDate = saved
+		closures.Unlock()
+	})
+}
+
+func TestRecordClosure(t *testing.T) {
+	restoreClosures(t)
+	date := "2020-10-13" // typhoon signal no. 8 in the morning
+	if !IsTradingDay(date) {
+		t.Fatalf("IsTradingDay() = false before the closure")
+	}
+	if err := RecordClosure(Closure{Date: date, Session: FullDay, Reason: "Typhoon Signal No. 8"}); err != nil {
+		t.Fatal(err)
+	}
+	if IsTradingDay(date) {
+		t.Errorf("IsTradingDay() = true after a full day closure")
+	}
+	if got, _ := PrevTradingDay("2020-10-14"); got != "2020-10-12" {
+		t.Errorf("PrevTradingDay() = %v, want 2020-10-12", got)
+	}
+}
+
+func TestLoadClosuresOnceContext(t *testing.T) {
+	t.Setenv("STOCK_CONNECT", "") // no database
+	for i := 0; i < 2; i++ {
+		if err := LoadClosuresOnceContext(context.Background()); err == nil {
+			t.Fatalf("LoadClosuresOnceContext() error = nil, want the connection error")
+		}
+		loaded.Lock()
+		ok := loaded.ok
+		loaded.Unlock()
+		if ok {
+			t.Errorf("LoadClosuresOnceContext() marked loaded after a failed load, not retried")
+		}
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"fmt"
 
 	"github.com/billylkc/stocklib/stock"
+	"github.com/billylkc/stocklib/util"
 )
 
 func main() {
-	err := stock.HketTest()
+	res, err := stock.GetEarningsForecast(5)
 	if err != nil {
 		fmt.Println(err)
 
 	}
+	fmt.Println(util.PrettyPrint(res))
 
 }
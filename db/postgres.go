@@ -1,9 +1,9 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 
 	_ "github.com/lib/pq"
@@ -12,7 +12,7 @@ import (
 func GetConnection() (*sql.DB, error) {
 	secret := os.Getenv("STOCK_CONNECT")
 	if secret == "" {
-		log.Fatal(fmt.Errorf("missing environment variable STOCK_CONNECT. Please check."))
+		return nil, fmt.Errorf("missing environment variable STOCK_CONNECT. Please check.")
 	}
 
 	db, err := sql.Open("postgres", secret)
@@ -22,10 +22,16 @@ func GetConnection() (*sql.DB, error) {
 	return db, nil
 }
 
-func RecordExists(table, date string) bool {
+// RecordExists checks if the table has records on the date
+func RecordExists(table, date string) (bool, error) {
+	return RecordExistsContext(context.Background(), table, date)
+}
+
+// RecordExistsContext checks if the table has records on the date, with the context for the query
+func RecordExistsContext(ctx context.Context, table, date string) (bool, error) {
 	db, err := GetConnection()
 	if err != nil {
-		return true
+		return false, err
 	}
 	queryF := `
     SELECT count(1) as cnt
@@ -33,17 +39,48 @@ func RecordExists(table, date string) bool {
     WHERE date = '%s'`
 
 	query := fmt.Sprintf(queryF, table, date)
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
 	defer rows.Close()
+	var num int
+	for rows.Next() {
+		if err := rows.Scan(&num); err != nil {
+			return false, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	return num > 0, nil // false as safe to insert
+}
+
+// ColumnExistsContext checks if the table has the column, e.g. for the columns added after the table was created
+func ColumnExistsContext(ctx context.Context, table, column string) (bool, error) {
+	db, err := GetConnection()
+	if err != nil {
+		return false, err
+	}
+	queryF := `
+    SELECT count(1) as cnt
+    FROM information_schema.columns
+    WHERE table_name = '%s' AND column_name = '%s'`
+
+	query := fmt.Sprintf(queryF, table, column)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
-		fmt.Println(err.Error())
+		return false, err
 	}
+	defer rows.Close()
 	var num int
 	for rows.Next() {
-		_ = rows.Scan(&num)
+		if err := rows.Scan(&num); err != nil {
+			return false, err
+		}
 	}
-	if num > 0 {
-		return true
+	if err := rows.Err(); err != nil {
+		return false, err
 	}
-	return false // false as safe to insert
+	return num > 0, nil
 }
@@ -0,0 +1,13 @@
+package fx
+
+import (
+	"github.com/billylkc/stocklib/web"
+)
+
+// client is the shared http client of the rate loaders
+var client = web.New()
+
+// SetClient replaces the http client used by GetRates, e.g. to point the HKMA base url elsewhere
+func SetClient(c *web.Client) {
+	client = c
+}
@@ -0,0 +1,305 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/stock"
+	"github.com/billylkc/stocklib/web"
+	"github.com/gocarina/gocsv"
+	"github.com/lib/pq"
+)
+
+// Supported currencies
+const (
+	HKD = "HKD"
+	USD = "USD"
+	CNY = "CNY"
+)
+
+// Rate is the daily reference rate of a currency, in HKD per unit
+type Rate struct {
+	Date     string
+	Currency string
+	Rate     float64 // HKD per unit, e.g. 7.7553 for USD
+}
+
+// cache of the rates by currency and date, filled on demand
+var cache struct {
+	sync.Mutex
+	rates map[string]float64
+}
+
+// pageSize is the number of records per request to the HKMA api
+const pageSize = 1000
+
+// GetRates gets the daily HKMA reference rates of USD and CNY between from and to (inclusive)
+func GetRates(from, to string) ([]Rate, error) {
+	return GetRatesContext(context.Background(), from, to)
+}
+
+// GetRatesContext gets the daily HKMA reference rates of USD and CNY between from and to (inclusive)
+func GetRatesContext(ctx context.Context, from, to string) ([]Rate, error) {
+	var result []Rate
+
+	for _, d := range []string{from, to} {
+		if err := dateFormat(d); err != nil {
+			return result, err
+		}
+	}
+
+	// page through the records until a short page
+	for offset := 0; ; offset += pageSize {
+		link := client.URL(web.HKMA, fmt.Sprintf("/public/market-data-and-statistics/monthly-statistical-bulletin/er-ir/er-eeri-daily?offset=%d&pagesize=%d&from=%s&to=%s", offset, pageSize, from, to))
+		rates, n, err := getRatesPage(ctx, link)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, rates...)
+		if n < pageSize {
+			break
+		}
+	}
+	return result, nil
+}
+
+// getRatesPage gets a page of the HKMA reference rates, with the number of records on the page
+func getRatesPage(ctx context.Context, link string) ([]Rate, int, error) {
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return []Rate{}, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return []Rate{}, 0, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	return parseRates(res.Body)
+}
+
+// parseRates parses the HKMA daily exchange rates json, with the number of records
+func parseRates(r io.Reader) ([]Rate, int, error) {
+	var result []Rate
+
+	var response struct {
+		Header struct {
+			Success bool   `json:"success"`
+			ErrMsg  string `json:"err_msg"`
+		} `json:"header"`
+		Result struct {
+			Records []struct {
+				EndOfDay string  `json:"end_of_day"`
+				USD      float64 `json:"usd"`
+				CNY      float64 `json:"cny"`
+			} `json:"records"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(r).Decode(&response); err != nil {
+		return result, 0, err
+	}
+	if !response.Header.Success {
+		return result, 0, fmt.Errorf("hkma error - %s", response.Header.ErrMsg)
+	}
+
+	for _, rec := range response.Result.Records {
+		if rec.USD > 0 {
+			result = append(result, Rate{Date: rec.EndOfDay, Currency: USD, Rate: rec.USD})
+		}
+		if rec.CNY > 0 {
+			result = append(result, Rate{Date: rec.EndOfDay, Currency: CNY, Rate: rec.CNY})
+		}
+	}
+	return result, len(response.Result.Records), nil
+}
+
+// LoadCSV loads the rates of a currency from a csv with Date and Value columns, e.g. the quandl FX csv.
+// Values are in HKD per unit of the currency.
+func LoadCSV(currency string, r io.Reader) ([]Rate, error) {
+	var rows []struct {
+		Date  string `csv:"Date"`
+		Value string `csv:"Value"`
+	}
+	if err := gocsv.Unmarshal(r, &rows); err != nil {
+		return []Rate{}, err
+	}
+
+	var result []Rate
+	for _, row := range rows {
+		v, err := strconv.ParseFloat(strings.TrimSpace(row.Value), 64)
+		if err != nil || v <= 0 {
+			continue
+		}
+		result = append(result, Rate{Date: row.Date, Currency: currency, Rate: v})
+	}
+	return result, nil
+}
+
+// Insert inserts to the fx_rate table
+func Insert(data []Rate) error {
+	return InsertContext(context.Background(), data)
+}
+
+// InsertContext inserts to the fx_rate table
+func InsertContext(ctx context.Context, data []Rate) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("fx_rate", "date", "currency", "rate"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Currency, model.Rate)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// Convert converts the amount between currencies with the latest rates on or before the date
+func Convert(amount float64, from, to, date string) (float64, error) {
+	return ConvertContext(context.Background(), amount, from, to, date)
+}
+
+// ConvertContext converts the amount between currencies with the latest rates on or before the date
+func ConvertContext(ctx context.Context, amount float64, from, to, date string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := getRate(ctx, from, date)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := getRate(ctx, to, date)
+	if err != nil {
+		return 0, err
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// Converter binds the context to ConvertContext, e.g. for stock.ConvertActions
+func Converter(ctx context.Context) stock.Converter {
+	return func(amount float64, from, to, date string) (float64, error) {
+		return ConvertContext(ctx, amount, from, to, date)
+	}
+}
+
+// NormaliseIndustry converts the market cap and turnover of the industry records to a single currency,
+// with the trading currency derived from the code
+func NormaliseIndustry(data []stock.Industry, to string) ([]stock.Industry, error) {
+	result := make([]stock.Industry, len(data))
+	for i, d := range data {
+		code, err := strconv.Atoi(d.CodeF)
+		if err != nil {
+			return result, err
+		}
+		from := stock.Classify(code, "").Currency
+
+		marketCap, err := Convert(float64(d.MarketCap), from, to, d.Date)
+		if err != nil {
+			return result, err
+		}
+		turnover, err := Convert(float64(d.Turnover), from, to, d.Date)
+		if err != nil {
+			return result, err
+		}
+		d.MarketCap = int(math.Round(marketCap))
+		d.Turnover = int(math.Round(turnover))
+		result[i] = d
+	}
+	return result, nil
+}
+
+// getRate gets the HKD per unit of a currency on or before the date, from the cache or the db
+func getRate(ctx context.Context, currency, date string) (float64, error) {
+	if currency == HKD {
+		return 1, nil
+	}
+
+	key := currency + date
+	cache.Lock()
+	rate, ok := cache.rates[key]
+	cache.Unlock()
+	if ok {
+		return rate, nil
+	}
+
+	database, err := db.GetConnection()
+	if err != nil {
+		return 0, err
+	}
+	queryF := `
+    SELECT
+       rate
+    FROM
+       fx_rate
+    WHERE
+       currency = '%s'
+       AND date <= '%s'
+    ORDER BY
+       date desc
+    LIMIT 1;
+    `
+	query := fmt.Sprintf(queryF, currency, date)
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		_ = rows.Scan(&rate)
+	}
+	if rate == 0 {
+		return 0, fmt.Errorf("no fx rate - %s - %s", currency, date)
+	}
+
+	cache.Lock()
+	if cache.rates == nil {
+		cache.rates = make(map[string]float64)
+	}
+	cache.rates[key] = rate
+	cache.Unlock()
+	return rate, nil
+}
+
+// dateFormat checks the date is in YYYY-MM-DD format
+func dateFormat(date string) error {
+	_, err := time.Parse("2006-01-02", date)
+	return err
+}
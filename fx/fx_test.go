@@ -0,0 +1,182 @@
+package fx
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestParseRates(t *testing.T) {
+	f, err := os.Open("testdata/er-eeri-daily.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, n, err := parseRates(f)
+	if err != nil {
+		t.Fatalf("parseRates() error = %v", err)
+	}
+	want := []Rate{
+		{Date: "2021-03-12", Currency: USD, Rate: 7.7653},
+		{Date: "2021-03-12", Currency: CNY, Rate: 1.1926},
+		{Date: "2021-03-11", Currency: USD, Rate: 7.7637},
+		{Date: "2021-03-11", Currency: CNY, Rate: 1.1955},
+		{Date: "2021-03-10", Currency: USD, Rate: 7.7662},
+		{Date: "2021-03-10", Currency: CNY, Rate: 1.1923},
+		{Date: "2021-03-09", Currency: USD, Rate: 7.7634}, // no cny
+	}
+	if n != 4 {
+		t.Errorf("parseRates() records = %d, want 4", n)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRates() = %v, want %v", got, want)
+	}
+}
+
+func TestParseRates_Error(t *testing.T) {
+	body := `{"header":{"success":false,"err_code":"1004","err_msg":"Invalid date"},"result":{}}`
+	if _, _, err := parseRates(strings.NewReader(body)); err == nil {
+		t.Errorf("parseRates() error = nil, want the hkma error")
+	}
+}
+
+func TestGetRates_Paging(t *testing.T) {
+	total := pageSize + 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var offset int
+		fmt.Sscanf(r.URL.Query().Get("offset"), "%d", &offset)
+
+		type record struct {
+			EndOfDay string  `json:"end_of_day"`
+			USD      float64 `json:"usd"`
+		}
+		var records []record
+		for i := offset; i < total && i < offset+pageSize; i++ {
+			records = append(records, record{EndOfDay: fmt.Sprintf("day-%d", i), USD: 7.75})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"header": map[string]interface{}{"success": true},
+			"result": map[string]interface{}{"records": records},
+		})
+	}))
+	defer server.Close()
+
+	c := web.New()
+	c.SetBaseURL(web.HKMA, server.URL)
+	SetClient(c)
+	defer SetClient(web.New())
+
+	got, err := GetRates("2017-01-01", "2021-03-12")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(got) != total {
+		t.Errorf("GetRates() = %d rates, want %d", len(got), total)
+	}
+	if len(got) > 0 && got[len(got)-1].Date != fmt.Sprintf("day-%d", total-1) {
+		t.Errorf("GetRates() last date = %s, want day-%d", got[len(got)-1].Date, total-1)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		csv      string
+		want     []Rate
+		wantErr  bool
+	}{
+		{
+			name:     "quandl fx",
+			currency: USD,
+			csv:      "Date,Value\n2021-03-12,7.7653\n2021-03-11,7.7637\n",
+			want: []Rate{
+				{Date: "2021-03-12", Currency: USD, Rate: 7.7653},
+				{Date: "2021-03-11", Currency: USD, Rate: 7.7637},
+			},
+		},
+		{
+			name:     "skip missing and invalid values",
+			currency: CNY,
+			csv:      "Date,Value\n2021-03-12,1.1926\n2021-03-11,\n2021-03-10,n/a\n2021-03-09,0\n",
+			want: []Rate{
+				{Date: "2021-03-12", Currency: CNY, Rate: 1.1926},
+			},
+		},
+		{
+			name:     "spaces around the value",
+			currency: USD,
+			csv:      "Date,Value\n2021-03-12, 7.7653 \n",
+			want: []Rate{
+				{Date: "2021-03-12", Currency: USD, Rate: 7.7653},
+			},
+		},
+		{
+			name:     "empty",
+			currency: USD,
+			csv:      "",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadCSV(tt.currency, strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	// seed the cache so the rates are not read from the db
+	cache.Lock()
+	saved := cache.rates
+	cache.rates = map[string]float64{
+		USD + "2021-03-12": 7.7653,
+		CNY + "2021-03-12": 1.1926,
+	}
+	cache.Unlock()
+	defer func() {
+		cache.Lock()
+		cache.rates = saved
+		cache.Unlock()
+	}()
+
+	tests := []struct {
+		name   string
+		amount float64
+		from   string
+		to     string
+		want   float64
+	}{
+		{"same currency", 100, USD, USD, 100},
+		{"to hkd", 100, USD, HKD, 776.53},
+		{"from hkd", 776.53, HKD, USD, 100},
+		{"cross rate", 100, USD, CNY, 100 * 7.7653 / 1.1926},
+		{"inverse cross rate", 100 * 7.7653 / 1.1926, CNY, USD, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.amount, tt.from, tt.to, "2021-03-12")
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
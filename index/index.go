@@ -0,0 +1,276 @@
+package index
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/stock"
+	"github.com/billylkc/stocklib/util"
+	"github.com/lib/pq"
+	"github.com/xuri/excelize/v2"
+)
+
+// Supported indexes
+const (
+	HSI    = "HSI"    // Hang Seng Index
+	HSCEI  = "HSCEI"  // Hang Seng China Enterprises Index
+	HSTECH = "HSTECH" // Hang Seng TECH Index
+)
+
+// Constituent of an index, effective from a date
+type Constituent struct {
+	Index         string
+	EffectiveDate string
+	Code          string // code in 5 digit format, e.g. 00005
+	Name          string
+	Weight        float64 // (%)
+}
+
+// LoadFile loads the constituents from a fact sheet file, either csv or xlsx
+func LoadFile(index, effectiveDate, path string) ([]Constituent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return []Constituent{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(index, effectiveDate, f)
+	case ".xlsx":
+		return LoadXLSX(index, effectiveDate, f)
+	}
+	return []Constituent{}, fmt.Errorf("unsupported file - %s", path)
+}
+
+// LoadCSV loads the constituents from a fact sheet csv
+func LoadCSV(index, effectiveDate string, r io.Reader) ([]Constituent, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // fact sheets have title rows above the header
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return []Constituent{}, err
+	}
+	return parseRows(index, effectiveDate, rows)
+}
+
+// LoadXLSX loads the constituents from the first sheet of a fact sheet xlsx
+func LoadXLSX(index, effectiveDate string, r io.Reader) ([]Constituent, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return []Constituent{}, err
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return []Constituent{}, err
+	}
+	return parseRows(index, effectiveDate, rows)
+}
+
+// Insert inserts to the index_constituent table
+func Insert(data []Constituent) error {
+	return InsertContext(context.Background(), data)
+}
+
+// InsertContext inserts to the index_constituent table
+func InsertContext(ctx context.Context, data []Constituent) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("index_constituent", "index", "effectivedate", "code", "name", "weight"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Index, model.EffectiveDate, model.Code, model.Name, model.Weight)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// GetConstituents gets the constituents of an index in effect on a date
+func GetConstituents(index, date string) ([]Constituent, error) {
+	return GetConstituentsContext(context.Background(), index, date)
+}
+
+// GetConstituentsContext gets the constituents of an index in effect on a date
+func GetConstituentsContext(ctx context.Context, index, date string) ([]Constituent, error) {
+	var result []Constituent
+	database, err := db.GetConnection()
+	if err != nil {
+		return result, err
+	}
+
+	queryF := `
+    SELECT
+       index, effectivedate, code, name, weight
+    FROM
+       index_constituent
+    WHERE
+       index = '%s'
+       AND effectivedate = (
+          SELECT max(effectivedate)
+          FROM index_constituent
+          WHERE index = '%s' AND effectivedate <= '%s'
+       )
+    ORDER BY
+       weight desc;
+    `
+	query := fmt.Sprintf(queryF, index, index, date)
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c             Constituent
+			effectiveDate time.Time
+		)
+		_ = rows.Scan(&c.Index, &effectiveDate, &c.Code, &c.Name, &c.Weight)
+		c.EffectiveDate = effectiveDate.Format("2006-01-02")
+		result = append(result, c)
+	}
+	if len(result) == 0 {
+		return result, fmt.Errorf("no constituents - %s - %s", index, date)
+	}
+	return result, nil
+}
+
+// Codes gets the codes of the constituents, e.g. for limiting the universe of the loaders
+func Codes(data []Constituent) []int {
+	var codes []int
+	for _, c := range data {
+		code, err := strconv.Atoi(c.Code)
+		if err == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// FilterIndustry keeps the industry records of the constituents only
+func FilterIndustry(data []stock.Industry, constituents []Constituent) []stock.Industry {
+	members := lookup(constituents)
+	var result []stock.Industry
+	for _, d := range data {
+		if _, ok := members[d.CodeF]; ok {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// FilterPerformance keeps the performance records of the constituents only
+func FilterPerformance(data []stock.Performance, constituents []Constituent) []stock.Performance {
+	members := lookup(constituents)
+	var result []stock.Performance
+	for _, d := range data {
+		if _, ok := members[d.Code]; ok {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// parseRows finds the header row with code, name and weight, then parses the rows below it
+func parseRows(index, effectiveDate string, rows [][]string) ([]Constituent, error) {
+	var result []Constituent
+
+	codeIdx, nameIdx, weightIdx := -1, -1, -1
+	for _, row := range rows {
+		if codeIdx < 0 {
+			for j, cell := range row {
+				cell = strings.ToLower(strings.TrimSpace(cell))
+				switch {
+				case strings.Contains(cell, "code") && codeIdx < 0:
+					codeIdx = j
+				case strings.Contains(cell, "name") && nameIdx < 0:
+					nameIdx = j
+				case strings.Contains(cell, "weight") && weightIdx < 0:
+					weightIdx = j
+				}
+			}
+			if codeIdx < 0 || weightIdx < 0 {
+				codeIdx, nameIdx, weightIdx = -1, -1, -1 // not the header row
+			}
+			continue
+		}
+		if codeIdx >= len(row) || weightIdx >= len(row) {
+			continue
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(strings.ReplaceAll(row[codeIdx], ".HK", "")))
+		if err != nil {
+			continue // e.g. footnotes
+		}
+		weight, _ := util.ParseF(strings.TrimSpace(row[weightIdx]))
+
+		var name string
+		if nameIdx >= 0 && nameIdx < len(row) {
+			name = strings.TrimSpace(row[nameIdx])
+		}
+
+		rec := Constituent{
+			Index:         index,
+			EffectiveDate: effectiveDate,
+			Code:          fmt.Sprintf("%05d", code),
+			Name:          name,
+			Weight:        weight,
+		}
+		result = append(result, rec)
+	}
+	if len(result) == 0 {
+		return result, errors.New("no constituents found in the file")
+	}
+	return result, nil
+}
+
+// lookup maps the constituents by code
+func lookup(constituents []Constituent) map[string]Constituent {
+	members := make(map[string]Constituent)
+	for _, c := range constituents {
+		members[c.Code] = c
+	}
+	return members
+}
@@ -0,0 +1,50 @@
+package index
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/billylkc/stocklib/stock"
+)
+
+func TestLoadCSV(t *testing.T) {
+	sheet := `Hang Seng Index
+As at 26/02/2021
+Stock Code,Stock Name,Weighting (%)
+700,TENCENT,10.12
+5,HSBC HOLDINGS,7.80
+Remark: weightings are rounded
+`
+	got, err := LoadCSV(HSI, "2021-03-08", strings.NewReader(sheet))
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	want := []Constituent{
+		{Index: HSI, EffectiveDate: "2021-03-08", Code: "00700", Name: "TENCENT", Weight: 10.12},
+		{Index: HSI, EffectiveDate: "2021-03-08", Code: "00005", Name: "HSBC HOLDINGS", Weight: 7.8},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadCSV() = %v, want %v", got, want)
+	}
+}
+
+func TestRelativePerformance(t *testing.T) {
+	constituents := []Constituent{
+		{Index: HSI, Code: "00700", Weight: 75},
+		{Index: HSI, Code: "00005", Weight: 25},
+	}
+	data := []stock.Performance{
+		{Code: "00700", OneM: 10},
+		{Code: "00005", OneM: -10},
+		{Code: "00001", OneM: 50}, // not a constituent
+	}
+
+	got := RelativePerformance(data, constituents)
+	if len(got) != 2 {
+		t.Fatalf("RelativePerformance() got %d records, want 2", len(got))
+	}
+	if got[0].OneM != 5 || got[1].OneM != -15 {
+		t.Errorf("RelativePerformance() = %v, want OneM 5 and -15", got)
+	}
+}
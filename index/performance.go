@@ -0,0 +1,84 @@
+package index
+
+import (
+	"github.com/billylkc/stocklib/stock"
+)
+
+// Relative is the performance of a constituent relative to the index, in % points
+type Relative struct {
+	Date   string
+	Index  string
+	Code   string
+	ThreeY float64 // 3-years
+	OneY   float64 // 1-year
+	SixM   float64 // 6-months
+	ThreeM float64 // 3-months
+	OneM   float64 // 1-months
+	OneW   float64 // 1-week
+	Ytd    float64 // year to date
+}
+
+// IndexPerformance derives the weighted performance of the index from the constituents' performance.
+// Weights are normalised over the constituents found in the data.
+func IndexPerformance(data []stock.Performance, constituents []Constituent) stock.Performance {
+	members := lookup(constituents)
+
+	var (
+		result stock.Performance
+		total  float64
+	)
+	for _, d := range data {
+		c, ok := members[d.Code]
+		if !ok {
+			continue
+		}
+		w := c.Weight
+		result.Date = d.Date
+		result.ThreeY += d.ThreeY * w
+		result.OneY += d.OneY * w
+		result.SixM += d.SixM * w
+		result.ThreeM += d.ThreeM * w
+		result.OneM += d.OneM * w
+		result.OneW += d.OneW * w
+		result.Ytd += d.Ytd * w
+		total += w
+	}
+	if total == 0 {
+		return result
+	}
+
+	result.ThreeY /= total
+	result.OneY /= total
+	result.SixM /= total
+	result.ThreeM /= total
+	result.OneM /= total
+	result.OneW /= total
+	result.Ytd /= total
+	if len(constituents) > 0 {
+		result.Code = constituents[0].Index
+	}
+	return result
+}
+
+// RelativePerformance derives the performance of each constituent against the index
+func RelativePerformance(data []stock.Performance, constituents []Constituent) []Relative {
+	var result []Relative
+
+	idx := IndexPerformance(data, constituents)
+	for _, d := range FilterPerformance(data, constituents) {
+		rec := Relative{
+			Date:   d.Date,
+			Index:  idx.Code,
+			Code:   d.Code,
+			ThreeY: d.ThreeY - idx.ThreeY,
+			OneY:   d.OneY - idx.OneY,
+			SixM:   d.SixM - idx.SixM,
+			ThreeM: d.ThreeM - idx.ThreeM,
+			OneM:   d.OneM - idx.OneM,
+			OneW:   d.OneW - idx.OneW,
+			Ytd:    d.Ytd - idx.Ytd,
+		}
+		result = append(result, rec)
+	}
+	return result
+}
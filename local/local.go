@@ -1,11 +1,14 @@
 package local
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/fx"
+	"github.com/billylkc/stocklib/stock"
 	"github.com/billylkc/stocklib/util"
 )
 
@@ -20,6 +23,63 @@ type StockPrice struct {
 
 // GetStockPrice gets the historical stock price of a certain code
 func GetStockPrice(code ...int) ([]StockPrice, error) {
+	return GetStockPriceContext(context.Background(), code...)
+}
+
+// GetStockPriceContext gets the historical stock price of a certain code
+func GetStockPriceContext(ctx context.Context, code ...int) ([]StockPrice, error) {
+	result, err := getStockPrice(ctx, code...)
+	if err != nil {
+		return result, err
+	}
+	deriveChanges(result)
+	return result, nil
+}
+
+// GetAdjustedStockPrice gets the historical stock price of a certain code, adjusted by the corporate actions
+func GetAdjustedStockPrice(mode stock.AdjustMode, code ...int) ([]StockPrice, error) {
+	return GetAdjustedStockPriceContext(context.Background(), mode, code...)
+}
+
+// GetAdjustedStockPriceContext gets the historical stock price of a certain code, adjusted by the corporate actions
+func GetAdjustedStockPriceContext(ctx context.Context, mode stock.AdjustMode, code ...int) ([]StockPrice, error) {
+	result, err := getStockPrice(ctx, code...)
+	if err != nil {
+		return result, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, c := range code {
+		codeF := fmt.Sprintf("%05d", c)
+		actions, err := GetCorporateActionsContext(ctx, c, "1900-01-01", today)
+		if err != nil {
+			return result, err
+		}
+		actions = stock.ConvertActions(actions, fx.Converter(ctx)) // e.g. usd dividends into hkd
+
+		var (
+			idx    []int
+			dates  []string
+			closes []float64
+		)
+		for i, sp := range result {
+			if sp.Code == codeF {
+				idx = append(idx, i)
+				dates = append(dates, sp.DateRaw.Format("2006-01-02"))
+				closes = append(closes, sp.Close)
+			}
+		}
+		factors := stock.AdjustFactors(actions, dates, closes, mode)
+		for j, i := range idx {
+			result[i].Close = result[i].Close * factors[j]
+		}
+	}
+	deriveChanges(result)
+	return result, nil
+}
+
+// getStockPrice queries the unadjusted stock price of certain codes
+func getStockPrice(ctx context.Context, code ...int) ([]StockPrice, error) {
 	var result []StockPrice
 	database, err := db.GetConnection()
 	if err != nil {
@@ -45,20 +105,28 @@ func GetStockPrice(code ...int) ([]StockPrice, error) {
     `
 	query := fmt.Sprintf(queryF, strings.Join(codeList, ","))
 	fmt.Println(query)
-	rows, err := database.Query(query)
-	defer rows.Close()
+	rows, err := database.QueryContext(ctx, query)
 	if err != nil {
-		fmt.Println(err.Error())
+		return result, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var sp StockPrice
-		_ = rows.Scan(&sp.Code, &sp.DateRaw, &sp.Close)
+		if err := rows.Scan(&sp.Code, &sp.DateRaw, &sp.Close); err != nil {
+			return result, err
+		}
 		sp.Date = sp.DateRaw.Format("02/01")
 		result = append(result, sp)
 	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
 
-	// Derive % changes on Close
+// deriveChanges derives the % changes on Close
+func deriveChanges(result []StockPrice) {
 	for i, _ := range result {
 		var changes float64
 		if i < len(result)-1 {
@@ -67,6 +135,186 @@ func GetStockPrice(code ...int) ([]StockPrice, error) {
 		result[i].Changes = changes
 		result[i].ChangesF = util.PercentFormat(changes)
 	}
+}
+
+// SouthboundHolding is the southbound shareholding of a code along with its close price
+type SouthboundHolding struct {
+	Code         string
+	DateRaw      time.Time // real date format
+	Date         string    // date in string format, DD/MM
+	Close        float64
+	Shareholding int
+	Pct          float64 // % of the total number of issued shares
+	Change       int     // changes on shareholding
+	ChangesF     string  // Percentage changes on shareholding. Formatted with +/- sign
+}
+
+// GetSouthboundHolding gets the southbound holding history of a certain code next to its close
+func GetSouthboundHolding(code int) ([]SouthboundHolding, error) {
+	return GetSouthboundHoldingContext(context.Background(), code)
+}
+
+// GetSouthboundHoldingContext gets the southbound holding history of a certain code next to its close
+func GetSouthboundHoldingContext(ctx context.Context, code int) ([]SouthboundHolding, error) {
+	var result []SouthboundHolding
+	database, err := db.GetConnection()
+	if err != nil {
+		return result, err
+	}
+
+	queryF := `
+    SELECT
+       h.code, h.date, COALESCE(s.close, 0), h.shareholding, h.pct
+    FROM
+       connect_holding h
+    LEFT JOIN
+       stock s ON s.code = h.code AND s.date = h.date
+    WHERE
+       h.code = '%05d'
+    ORDER BY
+       h.date desc
+    LIMIT 50;
+    `
+	query := fmt.Sprintf(queryF, code)
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sh SouthboundHolding
+		_ = rows.Scan(&sh.Code, &sh.DateRaw, &sh.Close, &sh.Shareholding, &sh.Pct)
+		sh.Date = sh.DateRaw.Format("02/01")
+		result = append(result, sh)
+	}
+
+	// Derive changes on shareholding
+	for i := range result {
+		var changes float64
+		if i < len(result)-1 {
+			result[i].Change = result[i].Shareholding - result[i+1].Shareholding
+			changes = util.PercentChange(float64(result[i].Shareholding), float64(result[i+1].Shareholding))
+		}
+		result[i].ChangesF = util.PercentFormat(changes)
+	}
+
+	return result, nil
+}
+
+// ShortRatio is the full day short selling volume against the total volume of a code
+type ShortRatio struct {
+	Code        string
+	DateRaw     time.Time // real date format
+	Date        string    // date in string format, DD/MM
+	Close       float64
+	Volume      int
+	ShortVolume int
+	Ratio       float64 // short volume / volume (%)
+	RatioF      string  // Short ratio, formatted to 1 d.p
+}
+
+// GetShortRatio gets the historical short ratio of certain codes along with the close price
+func GetShortRatio(code ...int) ([]ShortRatio, error) {
+	return GetShortRatioContext(context.Background(), code...)
+}
+
+// GetShortRatioContext gets the historical short ratio of certain codes along with the close price
+func GetShortRatioContext(ctx context.Context, code ...int) ([]ShortRatio, error) {
+	var result []ShortRatio
+	database, err := db.GetConnection()
+	if err != nil {
+		return result, err
+	}
+
+	var codeList []string
+	for _, c := range code {
+		codeList = append(codeList, fmt.Sprintf("'%05d'", c))
+	}
+
+	queryF := `
+    SELECT
+       s.code, s.date, s.close, s.volume, ss.volume
+    FROM
+       stock s
+    JOIN
+       short_selling ss ON ss.code = s.code AND ss.date = s.date AND ss.session = 'DAY'
+    WHERE
+       s.code IN (%s)
+    ORDER BY
+       s.date desc
+    LIMIT 50;
+    `
+	query := fmt.Sprintf(queryF, strings.Join(codeList, ","))
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sr ShortRatio
+		_ = rows.Scan(&sr.Code, &sr.DateRaw, &sr.Close, &sr.Volume, &sr.ShortVolume)
+		sr.Date = sr.DateRaw.Format("02/01")
+		result = append(result, sr)
+	}
+	deriveShortRatio(result)
+
+	return result, nil
+}
+
+// deriveShortRatio derives the short ratio on the full day volume
+func deriveShortRatio(result []ShortRatio) {
+	for i := range result {
+		var ratio float64
+		if result[i].Volume > 0 {
+			ratio = float64(result[i].ShortVolume) / float64(result[i].Volume) * 100
+		}
+		result[i].Ratio = ratio
+		result[i].RatioF = fmt.Sprintf("%.1f%%", ratio)
+	}
+}
+
+// GetCorporateActions gets the corporate actions of a certain code with ex-date between from and to (inclusive)
+func GetCorporateActions(code int, from, to string) ([]stock.CorporateAction, error) {
+	return GetCorporateActionsContext(context.Background(), code, from, to)
+}
+
+// GetCorporateActionsContext gets the corporate actions of a certain code with ex-date between from and to (inclusive)
+func GetCorporateActionsContext(ctx context.Context, code int, from, to string) ([]stock.CorporateAction, error) {
+	var result []stock.CorporateAction
+	database, err := db.GetConnection()
+	if err != nil {
+		return result, err
+	}
+
+	queryF := `
+    SELECT
+       code, exdate, type, cash, currency, ratio, price, description
+    FROM
+       corporate_action
+    WHERE
+       code = '%05d'
+       AND exdate BETWEEN '%s' AND '%s'
+    ORDER BY
+       exdate desc;
+    `
+	query := fmt.Sprintf(queryF, code, from, to)
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ca     stock.CorporateAction
+			exDate time.Time
+		)
+		_ = rows.Scan(&ca.Code, &exDate, &ca.Type, &ca.Cash, &ca.Currency, &ca.Ratio, &ca.Price, &ca.Description)
+		ca.ExDate = exDate.Format("2006-01-02")
+		result = append(result, ca)
+	}
 
 	return result, nil
 }
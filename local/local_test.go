@@ -0,0 +1,24 @@
+package local
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeriveShortRatio(t *testing.T) {
+	result := []ShortRatio{
+		{Code: "00005", Volume: 40000000, ShortVolume: 3210400},
+		{Code: "00700", Volume: 20000000, ShortVolume: 2001300},
+		{Code: "02800", Volume: 0, ShortVolume: 100}, // no volume
+	}
+	deriveShortRatio(result)
+
+	want := []ShortRatio{
+		{Code: "00005", Volume: 40000000, ShortVolume: 3210400, Ratio: 8.026, RatioF: "8.0%"},
+		{Code: "00700", Volume: 20000000, ShortVolume: 2001300, Ratio: 10.0065, RatioF: "10.0%"},
+		{Code: "02800", Volume: 0, ShortVolume: 100, Ratio: 0, RatioF: "0.0%"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("deriveShortRatio() = %v, want %v", result, want)
+	}
+}
@@ -0,0 +1,36 @@
+package quandl
+
+import (
+	"github.com/billylkc/stocklib/web"
+)
+
+// client is the shared http client of the package, Quandl uses it unless WithClient is set
+var client = web.New()
+
+// SetClient sets the default http client of the package, used by GetQuotations and by Quandl without WithClient
+func SetClient(c *web.Client) {
+	client = c
+}
+
+// WithClient sets the http client of Quandl
+func WithClient(c *web.Client) Option {
+	return func(q *Quandl) {
+		q.client = c
+	}
+}
+
+// http gets the http client of Quandl
+func (q *Quandl) http() *web.Client {
+	if q.client != nil {
+		return q.client
+	}
+	return client
+}
+
+// base gets the base url of the api, from WithBaseURL or QUANDL_BASE_URL, else from the http client
+func (q *Quandl) base() string {
+	if q.baseURL != "" {
+		return q.baseURL
+	}
+	return q.http().URL(web.Quandl, "")
+}
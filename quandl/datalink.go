@@ -0,0 +1,152 @@
+package quandl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIError is the error body returned by the api, e.g. {"quandl_error": {"code": "QECx02", "message": "..."}}
+type APIError struct {
+	Status  int    // http status code
+	Code    string // e.g. QEAx01
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d %s - %s", e.Status, e.Code, e.Message)
+}
+
+// Systemic tells if the error fails every request rather than a single dataset,
+// e.g. QEAx01 for invalid api key or QELx01 for exceeded quota
+func (e *APIError) Systemic() bool {
+	switch e.Status {
+	case http.StatusUnauthorized, http.StatusTooManyRequests:
+		return true
+	}
+	return strings.HasPrefix(e.Code, "QEA") || strings.HasPrefix(e.Code, "QEL")
+}
+
+// isSystemic checks if the error is a systemic APIError
+func isSystemic(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Systemic()
+}
+
+// Metadata of a dataset
+type Metadata struct {
+	DatabaseCode        string   `json:"database_code"`
+	DatasetCode         string   `json:"dataset_code"`
+	Name                string   `json:"name"`
+	Description         string   `json:"description"`
+	RefreshedAt         string   `json:"refreshed_at"`
+	NewestAvailableDate string   `json:"newest_available_date"`
+	OldestAvailableDate string   `json:"oldest_available_date"`
+	ColumnNames         []string `json:"column_names"`
+	Frequency           string   `json:"frequency"`
+}
+
+// GetMetadata gets the metadata of the dataset of a stock, e.g. the newest available date
+func (q *Quandl) GetMetadata(code int) (Metadata, error) {
+	return q.GetMetadataContext(context.Background(), code)
+}
+
+// GetMetadataContext gets the metadata of the dataset of a stock, e.g. the newest available date
+func (q *Quandl) GetMetadataContext(ctx context.Context, code int) (Metadata, error) {
+	var result Metadata
+
+	token, err := getToken()
+	if err != nil {
+		return result, err
+	}
+	endpoint := fmt.Sprintf("%s/datasets/HKEX/%05d/metadata.json?api_key=%s", q.base(), code, token)
+
+	response, err := q.http().GetContext(ctx, endpoint)
+	if err != nil {
+		return result, errors.Wrap(err, "something is wrong with the request")
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return result, errors.Wrap(err, "something is wrong with the response")
+	}
+	if err := checkResponse(response.StatusCode, body); err != nil {
+		return result, err
+	}
+
+	var wrapper struct {
+		Dataset Metadata `json:"dataset"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return result, errors.Wrap(err, "unable to unmarshal the response")
+	}
+	return wrapper.Dataset, nil
+}
+
+// checkResponse converts the error body or the non 200 status to APIError
+func checkResponse(status int, body []byte) error {
+	var wrapper struct {
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"quandl_error"`
+	}
+	if json.Unmarshal(body, &wrapper) == nil && wrapper.Error != nil {
+		return &APIError{Status: status, Code: wrapper.Error.Code, Message: wrapper.Error.Message}
+	}
+	if status != http.StatusOK {
+		return &APIError{Status: status, Message: http.StatusText(status)}
+	}
+	return nil
+}
+
+// parseJSON parses the json dataset response, mapping the columns by the csv tags of HistoricalPrice
+func parseJSON(body []byte) ([]HistoricalPrice, error) {
+	var result []HistoricalPrice
+
+	var wrapper struct {
+		DatasetData struct {
+			ColumnNames []string        `json:"column_names"`
+			Data        [][]interface{} `json:"data"`
+		} `json:"dataset_data"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return result, err
+	}
+
+	columns := wrapper.DatasetData.ColumnNames
+	for _, row := range wrapper.DatasetData.Data {
+		values := make(map[string]interface{})
+		for i, v := range row {
+			if i < len(columns) {
+				values[columns[i]] = v
+			}
+		}
+
+		date, _ := values["Date"].(string)
+		rec := HistoricalPrice{
+			Date:     date,
+			Ask:      toFloat(values["Ask"]),
+			Bid:      toFloat(values["Bid"]),
+			Open:     toFloat(values["Previous Close"]), // open is missing in quandl, using prev close
+			High:     toFloat(values["High"]),
+			Low:      toFloat(values["Low"]),
+			Close:    toFloat(values["Nominal Price"]),
+			Volume:   int(toFloat(values["Share Volume (000)"])),
+			Turnover: int(toFloat(values["Turnover (000)"])),
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+// toFloat converts the json number to float, null as 0
+func toFloat(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
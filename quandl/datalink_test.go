@@ -0,0 +1,63 @@
+package quandl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_checkResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"csv", 200, "Date,Nominal Price\n2021-02-26,44.4\n", nil},
+		{"json", 200, `{"dataset_data":{"data":[]}}`, nil},
+		{"incorrect code", 404, `{"quandl_error":{"code":"QECx02","message":"You have submitted an incorrect Quandl code."}}`, &APIError{Status: 404, Code: "QECx02", Message: "You have submitted an incorrect Quandl code."}},
+		{"no body", 503, "", &APIError{Status: 503, Message: "Service Unavailable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkResponse(tt.status, []byte(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseJSON(t *testing.T) {
+	body := `{"dataset_data":{"column_names":["Date","Nominal Price","Net Change","Change (%)","Bid","Ask","P/E(x)","High","Low","Previous Close","Share Volume (000)","Turnover (000)","Lot Size"],
+	"data":[["2021-02-26",44.4,null,null,44.4,44.45,null,45.5,44.05,45.05,40112.0,1790243.0,null]]}}`
+
+	got, err := parseJSON([]byte(body))
+	if err != nil {
+		t.Fatalf("parseJSON() error = %v", err)
+	}
+	want := []HistoricalPrice{{Date: "2021-02-26", Ask: 44.45, Bid: 44.4, Open: 45.05, High: 45.5, Low: 44.05, Close: 44.4, Volume: 40112, Turnover: 1790243}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestAPIError_Systemic(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"invalid api key", &APIError{Status: 400, Code: "QEAx01"}, true},
+		{"exceeded quota", &APIError{Status: 429, Code: "QELx01"}, true},
+		{"too many requests", &APIError{Status: 429}, true},
+		{"incorrect code", &APIError{Status: 404, Code: "QECx02"}, false},
+		{"no body", &APIError{Status: 503}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Systemic(); got != tt.want {
+				t.Errorf("APIError.Systemic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package quandl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/pkg/errors"
+)
+
+var (
+	// e.g. `*    1 CKH HOLDINGS     HKD    59.80    59.85    59.80    60.50    59.25    6,109,443`
+	quotationRegex = regexp.MustCompile(`^[\s\*#%]*(\d{1,5})\s+(.+?)\s+([A-Z]{3})\s+([\d.,]+|-)\s+([\d.,]+|-)\s+([\d.,]+|-)\s+([\d.,]+|-)\s+([\d.,]+|-)\s+([\d,]+|-)\s*$`)
+
+	// e.g. `                               59.85                                 366,436,528`
+	closingRegex = regexp.MustCompile(`^\s+([\d.,]+|-)\s+([\d,]+|-)\s*$`)
+)
+
+// GetQuotations gets the closing prices of all securities from the HKEX daily quotations report
+func GetQuotations(date string) ([]HistoricalPrice, error) {
+	return GetQuotationsContext(context.Background(), date)
+}
+
+// GetQuotationsContext gets the closing prices of all securities from the HKEX daily quotations report
+func GetQuotationsContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	var result []HistoricalPrice
+
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return result, err
+	}
+
+	link := client.URL(web.HKEX, fmt.Sprintf("/eng/stat/smstat/dayquot/d%se.htm", d.Format("060102")))
+	response, err := client.GetContext(ctx, link)
+	if err != nil {
+		return result, errors.Wrap(err, "something is wrong with the request")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return result, fmt.Errorf("Data not ready - %s", date)
+	}
+	result, err = parseQuotations(date, response.Body)
+	for i := range result {
+		result[i].Source = HKEX{}.Name()
+	}
+	return result, err
+}
+
+// parseQuotations parses the two-line records in the quotations section of the report
+func parseQuotations(date string, r io.Reader) ([]HistoricalPrice, error) {
+	var result []HistoricalPrice
+
+	var (
+		started bool
+		pending *HistoricalPrice
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !started {
+			started = strings.Contains(line, "QUOTATIONS")
+			continue
+		}
+
+		// Second line of a record, closing and turnover
+		if pending != nil {
+			if m := closingRegex.FindStringSubmatch(line); m != nil {
+				pending.Close, _ = util.ParseF(strings.ReplaceAll(m[1], ",", ""))
+				pending.Turnover, _ = util.ParseN(m[2])
+				result = append(result, *pending)
+			}
+			pending = nil
+			continue
+		}
+
+		// First line of a record
+		if m := quotationRegex.FindStringSubmatch(line); m != nil {
+			code, err := util.ParseN(m[1])
+			if err != nil {
+				continue
+			}
+			rec := HistoricalPrice{
+				Code:  code,
+				CodeF: fmt.Sprintf("%05d", code),
+				Date:  date,
+			}
+			rec.Open, _ = util.ParseF(strings.ReplaceAll(m[4], ",", "")) // open is missing in the report too, using prev close
+			rec.Ask, _ = util.ParseF(strings.ReplaceAll(m[5], ",", ""))
+			rec.Bid, _ = util.ParseF(strings.ReplaceAll(m[6], ",", ""))
+			rec.High, _ = util.ParseF(strings.ReplaceAll(m[7], ",", ""))
+			rec.Low, _ = util.ParseF(strings.ReplaceAll(m[8], ",", ""))
+			rec.Volume, _ = util.ParseN(m[9])
+			pending = &rec
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	if len(result) == 0 {
+		return result, errors.New("no quotations found in the report")
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package quandl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_parseQuotations(t *testing.T) {
+	f, err := os.Open("testdata/d210226e.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := parseQuotations("2021-02-26", f)
+	if err != nil {
+		t.Fatalf("parseQuotations() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		idx  int
+		want HistoricalPrice
+	}{
+		{"traded", 0, HistoricalPrice{Code: 1, CodeF: "00001", Date: "2021-02-26", Ask: 59.85, Bid: 59.8, Open: 59.8, High: 60.5, Low: 59.25, Close: 59.85, Volume: 6109443, Turnover: 366436528}},
+		{"not traded", 1, HistoricalPrice{Code: 4, CodeF: "00004", Date: "2021-02-26", Open: 19.82, Close: 19.82}},
+		{"prefixed", 2, HistoricalPrice{Code: 5, CodeF: "00005", Date: "2021-02-26", Ask: 44.45, Bid: 44.4, Open: 45.05, High: 45.5, Low: 44.05, Close: 44.4, Volume: 40112392, Turnover: 1790243018}},
+	}
+	if len(got) != 4 {
+		t.Fatalf("parseQuotations() got %d records, want 4", len(got))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(got[tt.idx], tt.want) {
+				t.Errorf("parseQuotations() = %v, want %v", got[tt.idx], tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuotations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eng/stat/smstat/dayquot/d210226e.htm" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/d210226e.htm")
+	}))
+	defer server.Close()
+
+	c := web.New()
+	c.SetBaseURL("", server.URL)
+	SetClient(c)
+	defer SetClient(web.New())
+
+	tests := []struct {
+		name    string
+		date    string
+		want    int
+		wantErr bool
+	}{
+		{"recorded", "2021-02-26", 4, false},
+		{"not ready", "2021-03-01", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetQuotations(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetQuotations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.want {
+				t.Errorf("GetQuotations() got %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestHKEX_GetByDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/d210226e.htm")
+	}))
+	defer server.Close()
+
+	c := web.New()
+	c.SetBaseURL("", server.URL)
+	SetClient(c)
+	defer SetClient(web.New())
+
+	got, err := HKEX{Universe: []int{1, 5}}.GetByDate("2021-02-26")
+	if err != nil {
+		t.Fatalf("HKEX.GetByDate() error = %v", err)
+	}
+	var codes []int
+	for _, d := range got {
+		codes = append(codes, d.Code)
+	}
+	if !reflect.DeepEqual(codes, []int{1, 5}) {
+		t.Errorf("HKEX.GetByDate() codes = %v, want [1 5]", codes)
+	}
+
+	q := New(logrus.New())
+	q.SetUniverse([]int{4, 2800})
+	got, err = q.GetStockByDate("2021-02-26")
+	if err != nil {
+		t.Fatalf("Quandl.GetStockByDate() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Source != "hkex" {
+		t.Errorf("Quandl.GetStockByDate() = %v, want 2 records from hkex", got)
+	}
+}
@@ -1,15 +1,21 @@
 package quandl
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/billylkc/stocklib/calendar"
 	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/fx"
+	"github.com/billylkc/stocklib/local"
 	"github.com/billylkc/stocklib/stock"
+	"github.com/billylkc/stocklib/web"
 	"github.com/gocarina/gocsv"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
@@ -29,29 +35,76 @@ type HistoricalPrice struct {
 	Close    float64 `csv:"Nominal Price"`
 	Volume   int     `csv:"Share Volume (000)"`
 	Turnover int     `csv:"Turnover (000)"`
+	AdjClose float64 `csv:"-"` // adjusted close, only from yahoo
+	Source   string  `csv:"-"` // price source which served the bar, e.g. quandl
+}
+
+// FromYahoo converts the yahoo bars to HistoricalPrice, with the real open instead of previous close and the adjusted close
+func FromYahoo(data []stock.YahooPrice) []HistoricalPrice {
+	var result []HistoricalPrice
+	for _, d := range data {
+		result = append(result, HistoricalPrice{
+			Code:     d.Code,
+			CodeF:    d.CodeF,
+			Date:     d.Date,
+			Open:     d.Open,
+			High:     d.High,
+			Low:      d.Low,
+			Close:    d.Close,
+			AdjClose: d.AdjClose,
+			Volume:   d.Volume,
+			Source:   Yahoo{}.Name(),
+		})
+	}
+	return result
 }
 
 // Quandl
 type Quandl struct {
 	logger *logrus.Logger
 	limit  int
-	start  string // not using start date right now
+	start  string // only for GetRange
 	end    string
 	order  string
+
+	universe []int // limits GetStockByDate to these codes, e.g. index constituents
+
+	workers int     // concurrent requests of GetStockByDate
+	rate    float64 // requests per second, 0 for unlimited
+
+	baseURL string // overrides the base url of the client, e.g. https://www.quandl.com/api/v3
+	format  string // csv or json
+	client  *web.Client
 }
 
-type option func(*Quandl)
+// Option sets the settings of Quandl
+type Option func(*Quandl)
 
 // New as Quandl constructor
-func New(logger *logrus.Logger) Quandl {
+func New(logger *logrus.Logger, opts ...Option) Quandl {
 	today := time.Now().Format("2006-01-02")
 
-	return Quandl{
+	q := Quandl{
 		logger: logger,
 		limit:  10,
 		end:    today,
 		order:  "desc",
+		format: "csv",
+
+		// within the quota of 2,000 calls per 10 minutes
+		workers: 4,
+		rate:    3,
 	}
+	if base := os.Getenv("QUANDL_BASE_URL"); base != "" {
+		q.baseURL = base
+	}
+	q.option(opts...)
+	return q
+}
+
+// SetUniverse limits GetStockByDate to the codes, e.g. index.Codes of the constituents
+func (q *Quandl) SetUniverse(codes []int) {
+	q.universe = codes
 }
 
 // Dev for development
@@ -67,76 +120,243 @@ func Dev() {
 
 // GetStockByCode is a wrapper to get all the historical dat a for a single stock
 func (q *Quandl) GetStockByCode(code int) ([]HistoricalPrice, error) {
-	return q.GetStock(code, "")
+	return q.GetStockByCodeContext(context.Background(), code)
+}
+
+// GetStockByCodeContext is a wrapper to get all the historical dat a for a single stock
+func (q *Quandl) GetStockByCodeContext(ctx context.Context, code int) ([]HistoricalPrice, error) {
+	return q.GetStockContext(ctx, code, "")
+}
+
+// GetAdjustedStockByCode gets all the historical data for a single stock, adjusted by the stored corporate actions
+func (q *Quandl) GetAdjustedStockByCode(code int, mode stock.AdjustMode) ([]HistoricalPrice, error) {
+	return q.GetAdjustedStockByCodeContext(context.Background(), code, mode)
+}
+
+// GetAdjustedStockByCodeContext gets all the historical data for a single stock, adjusted by the stored corporate actions
+func (q *Quandl) GetAdjustedStockByCodeContext(ctx context.Context, code int, mode stock.AdjustMode) ([]HistoricalPrice, error) {
+	data, err := q.GetStockByCodeContext(ctx, code)
+	if err != nil {
+		return data, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	actions, err := local.GetCorporateActionsContext(ctx, code, "1900-01-01", today)
+	if err != nil {
+		return data, err
+	}
+	actions = stock.ConvertActions(actions, fx.Converter(ctx)) // e.g. usd dividends into hkd
+	return AdjustPrices(data, actions, mode), nil
+}
+
+// GetAdjustedStock gets the stock on a date, adjusted by the corporate actions up to today
+func (q *Quandl) GetAdjustedStock(code int, date string, mode stock.AdjustMode) ([]HistoricalPrice, error) {
+	return q.GetAdjustedStockContext(context.Background(), code, date, mode)
 }
 
-// GetStockByDate
+// GetAdjustedStockContext gets the stock on a date, adjusted by the corporate actions up to today
+func (q *Quandl) GetAdjustedStockContext(ctx context.Context, code int, date string, mode stock.AdjustMode) ([]HistoricalPrice, error) {
+	data, err := q.GetAdjustedStockByCodeContext(ctx, code, mode)
+	if err != nil || date == "" {
+		return data, err
+	}
+	for _, d := range data {
+		if d.Date == date {
+			return []HistoricalPrice{d}, nil
+		}
+	}
+	return []HistoricalPrice{}, errors.New("not found")
+}
+
+// AdjustPrices adjusts the prices by the corporate actions. Volumes are not adjusted.
+func AdjustPrices(data []HistoricalPrice, actions []stock.CorporateAction, mode stock.AdjustMode) []HistoricalPrice {
+	var (
+		dates  []string
+		closes []float64
+	)
+	for _, d := range data {
+		dates = append(dates, d.Date)
+		closes = append(closes, d.Close)
+	}
+	factors := stock.AdjustFactors(actions, dates, closes, mode)
+
+	result := make([]HistoricalPrice, len(data))
+	for i, d := range data {
+		f := factors[i]
+		d.Ask = d.Ask * f
+		d.Bid = d.Bid * f
+		d.Open = d.Open * f
+		d.High = d.High * f
+		d.Low = d.Low * f
+		d.Close = d.Close * f
+		result[i] = d
+	}
+	return result
+}
+
+// GetStockByDate gets all the companies' prices on a date from the HKEX daily quotations report,
+// falling back to getting the stocks one by one from quandl
 func (q *Quandl) GetStockByDate(date string) ([]HistoricalPrice, error) {
+	return q.GetStockByDateContext(context.Background(), date)
+}
+
+// GetStockByDateContext gets all the companies' prices on a date as GetStockByDate, stopping when the context is done
+func (q *Quandl) GetStockByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
 	var result []HistoricalPrice
 
-	companies, err := stock.GetCompanyList()
+	if err := calendar.LoadClosuresOnceContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		q.logger.Warnf("Unable to load the market closures, checking the weekends and holidays only - %v", err)
+	}
+	if !calendar.IsTradingDay(date) {
+		return result, fmt.Errorf("not a trading day - %s", date)
+	}
+
+	chain := NewChain(q.logger, HKEX{Universe: q.universe}, q)
+	return chain.GetByDateContext(ctx, date)
+}
+
+// getCompanies gets the codes actually trading on the date, from the universe if set, else the universe snapshots if available
+func getCompanies(ctx context.Context, date string, universe []int) ([]int, error) {
+	if len(universe) > 0 {
+		return universe, nil
+	}
+	companies, err := stock.GetUniverseContext(ctx, date)
 	if err != nil {
-		return result, err
+		return stock.GetCompanyListContext(ctx)
 	}
+	return companies, nil
+}
+
+// getStockByDate gets the stocks on a date from quandl with a pool of workers, limited by the requests per second.
+// Results are in the order of the companies. Systemic api errors, e.g. invalid api key or exceeded quota, abort the run.
+func (q *Quandl) getStockByDate(ctx context.Context, date string, companies []int) ([]HistoricalPrice, error) {
+	var result []HistoricalPrice
 
-	fmt.Printf("Getting date - %s - %d \n\n", date, len(companies))
+	if len(companies) == 0 {
+		return result, nil
+	}
+	if err := q.ready(ctx, date, companies[0]); err != nil {
+		return []HistoricalPrice{}, err
+	}
+	q.logger.Infof("Getting date - %s - %d companies, %d workers", date, len(companies), q.workers)
 
-	var counter int
-	for _, code := range companies {
+	run, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		// Check for consecutive failures
-		if counter >= 20 {
-			return []HistoricalPrice{}, fmt.Errorf("Data not ready - %s", date)
+	var tick <-chan time.Time
+	if q.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / q.rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var (
+		wg      sync.WaitGroup
+		once    sync.Once
+		abort   error
+		results = make([][]HistoricalPrice, len(companies))
+		jobs    = make(chan int)
+	)
+	for w := 0; w < q.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if tick != nil {
+					select {
+					case <-tick:
+					case <-run.Done():
+						continue
+					}
+				}
+				if run.Err() != nil {
+					continue
+				}
+				data, err := q.GetStockContext(run, companies[i], date)
+				if err != nil {
+					if isSystemic(err) {
+						once.Do(func() {
+							abort = err
+							cancel()
+						})
+					}
+					q.logger.Debugf("(%s) Getting stock - %d - %v", date, companies[i], err)
+					continue
+				}
+				q.logger.Debugf("(%s) Getting stock - %d - %d records", date, companies[i], len(data))
+				results[i] = data
+			}
+		}()
+	}
+
+feed:
+	for i := range companies {
+		select {
+		case jobs <- i:
+		case <-run.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		fmt.Printf("(%s) Getting stock - %d", date, code)
-		data, err := q.GetStock(code, date)
-		if err != nil {
-			counter += 1
-			// fmt.Printf("Error - %v \n", err)
-		} else {
-			counter = 0 // reset
-			result = append(result, data...)
+	if abort != nil {
+		return []HistoricalPrice{}, errors.Wrap(abort, "aborted getting the stocks")
+	}
+	if err := ctx.Err(); err != nil {
+		return []HistoricalPrice{}, err
+	}
+	for _, data := range results {
+		result = append(result, data...)
+	}
+	if len(result) == 0 {
+		return result, fmt.Errorf("Data not ready - %s", date)
+	}
+	return result, nil
+}
+
+// ready checks the newest available date of a dataset before getting all the stocks on a date.
+// Only the systemic api errors are returned, others are left to the individual requests.
+func (q *Quandl) ready(ctx context.Context, date string, code int) error {
+	meta, err := q.GetMetadataContext(ctx, code)
+	if err != nil {
+		if isSystemic(err) || ctx.Err() != nil {
+			return err
 		}
-		fmt.Printf(" - %d records \n", len(data))
+		return nil
+	}
+	if meta.NewestAvailableDate != "" && meta.NewestAvailableDate < date {
+		return fmt.Errorf("Data not ready - %s", date)
 	}
-	return result, err
+	return nil
 }
 
 // GetStock is the underlying function to get the stock by different code and date settings
 func (q *Quandl) GetStock(code int, date string) ([]HistoricalPrice, error) {
+	return q.GetStockContext(context.Background(), code, date)
+}
+
+// GetStockContext is the underlying function to get the stock by different code and date settings
+func (q *Quandl) GetStockContext(ctx context.Context, code int, date string) ([]HistoricalPrice, error) {
 	var data []HistoricalPrice
 
-	// Derive input
+	// Derive input, on a copy as the requests may run concurrently
+	r := *q
 	if date == "" {
 		today := time.Now().Format("2006-01-02")
-		q.option(setEndDate(today))
-		q.option(setLimit(10000))
+		r.option(setEndDate(today))
+		r.option(setLimit(10000))
 	} else {
-		q.option(setEndDate(date))
-		q.option(setLimit(10))
+		r.option(setEndDate(date))
+		r.option(setLimit(10))
 	}
 
-	codeF := fmt.Sprintf("%05d", code)
-	endpoint, _ := q.getEndpoint(code)
-
-	response, err := http.Get(endpoint)
+	data, err := r.fetch(ctx, code)
 	if err != nil {
-		return data, errors.Wrap(err, "something is wrong with the request")
-	}
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-
-	if err := gocsv.UnmarshalBytes(body, &data); err != nil {
-		q.logger.Error("unable to unmarshal the response")
-		return data, errors.New("unable to unmarshal the response")
-	}
-
-	for i, _ := range data {
-		data[i].Code = code
-		data[i].CodeF = codeF
-		data[i].Volume = data[i].Volume * 1000
-		data[i].Turnover = data[i].Turnover * 1000
+		return data, err
 	}
 
 	// Handle date logic
@@ -159,38 +379,129 @@ func (q *Quandl) GetStock(code int, date string) ([]HistoricalPrice, error) {
 	return result, nil
 }
 
+// GetRange gets the stock between start and end date (inclusive)
+func (q *Quandl) GetRange(code int, start, end string) ([]HistoricalPrice, error) {
+	return q.GetRangeContext(context.Background(), code, start, end)
+}
+
+// GetRangeContext gets the stock between start and end date (inclusive)
+func (q *Quandl) GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error) {
+	r := *q
+	r.option(setStartDate(start), setEndDate(end), setLimit(10000))
+
+	data, err := r.fetch(ctx, code)
+	if err != nil {
+		return data, err
+	}
+	if len(data) == 0 {
+		return data, errors.New("not found")
+	}
+	return data, nil
+}
+
+// fetch gets the dataset of a stock with the current settings
+func (q *Quandl) fetch(ctx context.Context, code int) ([]HistoricalPrice, error) {
+	var data []HistoricalPrice
+
+	codeF := fmt.Sprintf("%05d", code)
+	endpoint, err := q.getEndpoint(code)
+	if err != nil {
+		return data, err
+	}
+
+	response, err := q.http().GetContext(ctx, endpoint)
+	if err != nil {
+		return data, errors.Wrap(err, "something is wrong with the request")
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return data, errors.Wrap(err, "something is wrong with the response")
+	}
+
+	// Error bodies are always json, e.g. {"quandl_error": {"code": "QECx02", "message": "..."}}
+	if err := checkResponse(response.StatusCode, body); err != nil {
+		q.logger.Error(err.Error())
+		return data, err
+	}
+
+	if q.format == "json" {
+		data, err = parseJSON(body)
+		if err != nil {
+			q.logger.Error("unable to unmarshal the response")
+			return data, errors.Wrap(err, "unable to unmarshal the response")
+		}
+	} else {
+		if err := gocsv.UnmarshalBytes(body, &data); err != nil {
+			q.logger.Error("unable to unmarshal the response")
+			return data, errors.New("unable to unmarshal the response")
+		}
+	}
+
+	for i, _ := range data {
+		data[i].Code = code
+		data[i].CodeF = codeF
+		data[i].Volume = data[i].Volume * 1000
+		data[i].Turnover = data[i].Turnover * 1000
+		data[i].Source = q.Name()
+	}
+	return data, nil
+}
+
+// Insert inserts to the stock table, with the price source of each bar if the table has the source column,
+// i.e. ALTER TABLE stock ADD COLUMN source varchar(10) for the tables created before it
 func (q *Quandl) Insert(data []HistoricalPrice) error {
+	return q.InsertContext(context.Background(), data)
+}
+
+// InsertContext inserts to the stock table, with the price source of each bar if the table has the source column
+func (q *Quandl) InsertContext(ctx context.Context, data []HistoricalPrice) error {
 
 	if len(data) == 0 {
 		return errors.New("no records to be inserted")
 	}
 	fmt.Printf("Start inserting records - %d\n", len(data))
 
+	withSource, err := db.ColumnExistsContext(ctx, "stock", "source")
+	if err != nil {
+		return err
+	}
+	columns := []string{"date", "ask", "bid", "open", "high", "low", "close", "volume", "turnover", "code"}
+	if withSource {
+		columns = append(columns, "source")
+	} else {
+		q.logger.Warn("No source column in the stock table, skipping the price source")
+	}
+
 	db, err := db.GetConnection()
 	if err != nil {
 		return err
 	}
 
-	txn, err := db.Begin()
+	txn, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer txn.Commit()
 
-	stmt, err := txn.Prepare(pq.CopyIn("stock", "date", "ask", "bid", "open", "high", "low", "close", "volume", "turnover", "code"))
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("stock", columns...))
 	if err != nil {
 		return (err)
 	}
 
 	for _, model := range data {
-		_, err := stmt.Exec(model.Date, model.Ask, model.Bid, model.Open, model.High, model.Low, model.Close, model.Volume, model.Turnover, model.CodeF)
+		values := []interface{}{model.Date, model.Ask, model.Bid, model.Open, model.High, model.Low, model.Close, model.Volume, model.Turnover, model.CodeF}
+		if withSource {
+			values = append(values, model.Source)
+		}
+		_, err := stmt.ExecContext(ctx, values...)
 		if err != nil {
 			txn.Rollback()
 			return err
 		}
 	}
 
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -205,48 +516,86 @@ func (q *Quandl) Insert(data []HistoricalPrice) error {
 }
 
 // Option sets the options specified.
-func (q *Quandl) option(opts ...option) {
+func (q *Quandl) option(opts ...Option) {
 	for _, opt := range opts {
 		opt(q)
 	}
 }
 
-//getEndpoint gets the endpoint for the quandl api
+// getEndpoint gets the endpoint for the quandl api
 func (q *Quandl) getEndpoint(code int) (string, error) {
 	token, err := getToken()
 	if err != nil {
 		return "", err
 	}
 	codeF := fmt.Sprintf("%05d", code)
-	endpoint := fmt.Sprintf("https://www.quandl.com/api/v3/datasets/HKEX/%s/data.csv?limit=%d&end_date=%s&order=%s&api_key=%s", codeF, q.limit, q.end, q.order, token)
+	endpoint := fmt.Sprintf("%s/datasets/HKEX/%s/data.%s?limit=%d&end_date=%s&order=%s&api_key=%s", q.base(), codeF, q.format, q.limit, q.end, q.order, token)
+	if q.start != "" {
+		endpoint = endpoint + "&start_date=" + q.start
+	}
 	return endpoint, nil
 }
 
-// getToken returns the quandl api token
+// getToken returns the quandl api token, also known as the nasdaq data link api key
 func getToken() (string, error) {
-	token := os.Getenv("QUANDL_TOKEN")
-	if token == "" {
-		return "", errors.New("please check you env variable QUANDL_TOKEN")
+	for _, env := range []string{"QUANDL_TOKEN", "NASDAQ_DATA_LINK_API_KEY"} {
+		if token := os.Getenv(env); token != "" {
+			return token, nil
+		}
+	}
+	return "", errors.New("please check you env variable QUANDL_TOKEN or NASDAQ_DATA_LINK_API_KEY")
+}
+
+// WithBaseURL sets the base url of the api, e.g. https://www.quandl.com/api/v3 for the legacy endpoint
+func WithBaseURL(url string) Option {
+	return func(q *Quandl) {
+		q.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithFormat sets the dataset response format, either csv or json
+func WithFormat(format string) Option {
+	return func(q *Quandl) {
+		if format == "csv" || format == "json" {
+			q.format = format
+		}
+	}
+}
+
+// WithConcurrency sets the number of concurrent requests of GetStockByDate, at least 1
+func WithConcurrency(n int) Option {
+	return func(q *Quandl) {
+		if n > 0 {
+			q.workers = n
+		}
+	}
+}
+
+// WithRateLimit sets the requests per second of GetStockByDate, 0 for unlimited
+func WithRateLimit(rps float64) Option {
+	return func(q *Quandl) {
+		if rps >= 0 {
+			q.rate = rps
+		}
 	}
-	return token, nil
 }
 
-func setLimit(n int) option {
+func setLimit(n int) Option {
 	return func(q *Quandl) {
 		q.limit = n
 	}
 }
-func setOrder(settings string) option {
+func setOrder(settings string) Option {
 	return func(q *Quandl) {
 		q.order = settings
 	}
 }
-func setStartDate(settings string) option {
+func setStartDate(settings string) Option {
 	return func(q *Quandl) {
 		q.start = settings
 	}
 }
-func setEndDate(settings string) option {
+func setEndDate(settings string) Option {
 	return func(q *Quandl) {
 		q.end = settings
 	}
@@ -0,0 +1,130 @@
+package quandl
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/billylkc/stocklib/stock"
+	"github.com/sirupsen/logrus"
+)
+
+// newDatasetServer serves the metadata and a single bar on 2021-02-26 of each code,
+// or the error body by code
+func newDatasetServer(t *testing.T, errs map[string]string) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		parts := strings.Split(r.URL.Path, "/") // /datasets/HKEX/00005/data.csv
+		codeF := parts[len(parts)-2]
+		if body, ok := errs[codeF]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, body)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "metadata.json") {
+			fmt.Fprint(w, `{"dataset":{"newest_available_date":"2021-02-26"}}`)
+			return
+		}
+		fmt.Fprintf(w, "Date,Nominal Price\n2021-02-26,%s\n", strings.TrimLeft(codeF, "0"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestQuandl(srv *httptest.Server, opts ...Option) Quandl {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	return New(logger, append([]Option{WithBaseURL(srv.URL), WithRateLimit(0)}, opts...)...)
+}
+
+func Test_getStockByDate(t *testing.T) {
+	t.Setenv("QUANDL_TOKEN", "test")
+	srv, _ := newDatasetServer(t, map[string]string{
+		"00004": `{"quandl_error":{"code":"QECx02","message":"You have submitted an incorrect Quandl code."}}`,
+	})
+	q := newTestQuandl(srv, WithConcurrency(3))
+
+	got, err := q.getStockByDate(context.Background(), "2021-02-26", []int{1, 2, 3, 4, 5, 6, 7, 8})
+	if err != nil {
+		t.Fatalf("getStockByDate() error = %v", err)
+	}
+	var codes []int
+	for _, d := range got {
+		codes = append(codes, d.Code)
+		if d.Close != float64(d.Code) {
+			t.Errorf("getStockByDate() close of %d = %v", d.Code, d.Close)
+		}
+	}
+	want := []int{1, 2, 3, 5, 6, 7, 8}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("getStockByDate() codes = %v, want %v", codes, want)
+	}
+}
+
+func Test_getStockByDateAbort(t *testing.T) {
+	t.Setenv("QUANDL_TOKEN", "test")
+	srv, calls := newDatasetServer(t, map[string]string{
+		"00003": `{"quandl_error":{"code":"QELx01","message":"You have exceeded the API speed limit."}}`,
+	})
+	q := newTestQuandl(srv, WithConcurrency(1))
+
+	companies := make([]int, 100)
+	for i := range companies {
+		companies[i] = i + 1
+	}
+	_, err := q.getStockByDate(context.Background(), "2021-02-26", companies)
+	if !isSystemic(err) {
+		t.Fatalf("getStockByDate() error = %v, want systemic", err)
+	}
+	if n := atomic.LoadInt32(calls); n > 10 {
+		t.Errorf("getStockByDate() made %d requests after the systemic error", n)
+	}
+}
+
+func Test_getStockByDateNotReady(t *testing.T) {
+	t.Setenv("QUANDL_TOKEN", "test")
+	srv, calls := newDatasetServer(t, nil)
+	q := newTestQuandl(srv)
+
+	_, err := q.getStockByDate(context.Background(), "2021-03-01", []int{1, 2, 3})
+	if err == nil {
+		t.Fatal("getStockByDate() error = nil, want data not ready")
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("getStockByDate() made %d requests, want only the metadata", n)
+	}
+}
+
+func TestGetStockByDateCancelled(t *testing.T) {
+	t.Setenv("QUANDL_TOKEN", "test")
+	srv, calls := newDatasetServer(t, nil)
+	q := newTestQuandl(srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.GetStockByDateContext(ctx, "2021-02-26"); err != context.Canceled {
+		t.Errorf("GetStockByDateContext() error = %v, want %v", err, context.Canceled)
+	}
+	if n := atomic.LoadInt32(calls); n != 0 {
+		t.Errorf("GetStockByDateContext() made %d requests after the cancel", n)
+	}
+}
+
+func TestFromYahoo(t *testing.T) {
+	data := []stock.YahooPrice{
+		{Code: 5, CodeF: "00005", Symbol: "0005.HK", Date: "2021-02-26", Open: 45.05, High: 45.5, Low: 44.05, Close: 44.4, AdjClose: 42.95, Volume: 40112392},
+	}
+	want := []HistoricalPrice{
+		{Code: 5, CodeF: "00005", Date: "2021-02-26", Open: 45.05, High: 45.5, Low: 44.05, Close: 44.4, AdjClose: 42.95, Volume: 40112392, Source: "yahoo"},
+	}
+	if got := FromYahoo(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromYahoo() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,203 @@
+package quandl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/billylkc/stocklib/stock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotSupported is returned by the sources which cannot serve the request, e.g. by date on yahoo
+var ErrNotSupported = errors.New("not supported by the price source")
+
+// PriceSource is a vendor of the daily prices
+type PriceSource interface {
+	Name() string
+	GetRange(code int, start, end string) ([]HistoricalPrice, error) // between start and end (inclusive)
+	GetByDate(date string) ([]HistoricalPrice, error)                // all the companies on a date
+	GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error)
+	GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error)
+}
+
+var (
+	_ PriceSource = (*Quandl)(nil)
+	_ PriceSource = HKEX{}
+	_ PriceSource = Yahoo{}
+	_ PriceSource = (*Chain)(nil)
+)
+
+// Name of the quandl source
+func (q *Quandl) Name() string {
+	return "quandl"
+}
+
+// GetByDate gets the companies on a date one by one from quandl
+func (q *Quandl) GetByDate(date string) ([]HistoricalPrice, error) {
+	return q.GetByDateContext(context.Background(), date)
+}
+
+// GetByDateContext gets the companies on a date one by one from quandl, stopping when the context is done
+func (q *Quandl) GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	companies, err := getCompanies(ctx, date, q.universe)
+	if err != nil {
+		return []HistoricalPrice{}, err
+	}
+	return q.getStockByDate(ctx, date, companies)
+}
+
+// HKEX is the price source of the HKEX daily quotations report
+type HKEX struct {
+	Universe []int // limits GetByDate to these codes, else the universe snapshot or the company list
+}
+
+// Name of the hkex source
+func (HKEX) Name() string {
+	return "hkex"
+}
+
+// GetRange is not supported, the report covers a single date
+func (HKEX) GetRange(code int, start, end string) ([]HistoricalPrice, error) {
+	return []HistoricalPrice{}, ErrNotSupported
+}
+
+// GetRangeContext is not supported, the report covers a single date
+func (HKEX) GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error) {
+	return []HistoricalPrice{}, ErrNotSupported
+}
+
+// GetByDate gets the companies from the daily quotations report, the same universe as quandl
+func (h HKEX) GetByDate(date string) ([]HistoricalPrice, error) {
+	return h.GetByDateContext(context.Background(), date)
+}
+
+// GetByDateContext gets the companies from the daily quotations report, the same universe as quandl
+func (h HKEX) GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	var result []HistoricalPrice
+
+	companies, err := getCompanies(ctx, date, h.Universe)
+	if err != nil {
+		return result, err
+	}
+	data, err := GetQuotationsContext(ctx, date)
+	if err != nil {
+		return result, err
+	}
+
+	listed := make(map[int]bool)
+	for _, code := range companies {
+		listed[code] = true
+	}
+	for _, d := range data {
+		if listed[d.Code] {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// Yahoo is the price source of the yahoo finance history
+type Yahoo struct{}
+
+// Name of the yahoo source
+func (Yahoo) Name() string {
+	return "yahoo"
+}
+
+// GetRange gets the bars of a stock from yahoo, with the real open
+func (y Yahoo) GetRange(code int, start, end string) ([]HistoricalPrice, error) {
+	return y.GetRangeContext(context.Background(), code, start, end)
+}
+
+// GetRangeContext gets the bars of a stock from yahoo, with the real open
+func (Yahoo) GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error) {
+	data, err := stock.GetYahooPriceContext(ctx, code, start, end)
+	if err != nil {
+		return []HistoricalPrice{}, err
+	}
+	return FromYahoo(data), nil
+}
+
+// GetByDate is not supported, yahoo serves a single code per request
+func (Yahoo) GetByDate(date string) ([]HistoricalPrice, error) {
+	return []HistoricalPrice{}, ErrNotSupported
+}
+
+// GetByDateContext is not supported, yahoo serves a single code per request
+func (Yahoo) GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	return []HistoricalPrice{}, ErrNotSupported
+}
+
+// Chain tries the sources in priority order until one serves the request
+type Chain struct {
+	logger  *logrus.Logger
+	sources []PriceSource
+}
+
+// NewChain as Chain constructor, sources in priority order
+func NewChain(logger *logrus.Logger, sources ...PriceSource) *Chain {
+	return &Chain{
+		logger:  logger,
+		sources: sources,
+	}
+}
+
+// Name of the chain, with the names of the sources
+func (c *Chain) Name() string {
+	name := "chain"
+	for _, s := range c.sources {
+		name = name + ":" + s.Name()
+	}
+	return name
+}
+
+// GetRange gets the bars of a stock from the first source which serves it
+func (c *Chain) GetRange(code int, start, end string) ([]HistoricalPrice, error) {
+	return c.GetRangeContext(context.Background(), code, start, end)
+}
+
+// GetRangeContext gets the bars of a stock from the first source which serves it, stopping when the context is done
+func (c *Chain) GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error) {
+	return c.try(ctx, func(s PriceSource) ([]HistoricalPrice, error) {
+		return s.GetRangeContext(ctx, code, start, end)
+	})
+}
+
+// GetByDate gets the companies on a date from the first source which serves it
+func (c *Chain) GetByDate(date string) ([]HistoricalPrice, error) {
+	return c.GetByDateContext(context.Background(), date)
+}
+
+// GetByDateContext gets the companies on a date from the first source which serves it, stopping when the context is done
+func (c *Chain) GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	return c.try(ctx, func(s PriceSource) ([]HistoricalPrice, error) {
+		return s.GetByDateContext(ctx, date)
+	})
+}
+
+// try calls the sources in order, skipping the failed or empty results, and records the source on each bar.
+// A done context stops the chain instead of falling through to the next source.
+func (c *Chain) try(ctx context.Context, get func(PriceSource) ([]HistoricalPrice, error)) ([]HistoricalPrice, error) {
+	var errs []string
+	for _, s := range c.sources {
+		if err := ctx.Err(); err != nil {
+			return []HistoricalPrice{}, err
+		}
+		data, err := get(s)
+		if err == nil && len(data) > 0 {
+			for i := range data {
+				data[i].Source = s.Name()
+			}
+			return data, nil
+		}
+		if err == nil {
+			err = errors.New("no records")
+		}
+		if c.logger != nil && err != ErrNotSupported {
+			c.logger.Errorf("price source %s failed - %v", s.Name(), err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", s.Name(), err))
+	}
+	return []HistoricalPrice{}, fmt.Errorf("all price sources failed - %v", errs)
+}
@@ -0,0 +1,74 @@
+package quandl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeSource serves the fixed bars or error
+type fakeSource struct {
+	name string
+	data []HistoricalPrice
+	err  error
+}
+
+func (f fakeSource) Name() string { return f.name }
+
+func (f fakeSource) GetRange(code int, start, end string) ([]HistoricalPrice, error) {
+	return f.data, f.err
+}
+
+func (f fakeSource) GetByDate(date string) ([]HistoricalPrice, error) {
+	return f.data, f.err
+}
+
+func (f fakeSource) GetRangeContext(ctx context.Context, code int, start, end string) ([]HistoricalPrice, error) {
+	return f.data, f.err
+}
+
+func (f fakeSource) GetByDateContext(ctx context.Context, date string) ([]HistoricalPrice, error) {
+	return f.data, f.err
+}
+
+func TestChain(t *testing.T) {
+	bar := []HistoricalPrice{{Code: 5, Date: "2021-02-26", Close: 44.4}}
+
+	tests := []struct {
+		name       string
+		sources    []PriceSource
+		wantSource string
+		wantErr    bool
+	}{
+		{"first", []PriceSource{fakeSource{"a", bar, nil}, fakeSource{"b", bar, nil}}, "a", false},
+		{"fallback on error", []PriceSource{fakeSource{"a", nil, errors.New("outage")}, fakeSource{"b", bar, nil}}, "b", false},
+		{"fallback on empty", []PriceSource{fakeSource{"a", nil, nil}, fakeSource{"b", bar, nil}}, "b", false},
+		{"fallback on not supported", []PriceSource{fakeSource{"a", nil, ErrNotSupported}, fakeSource{"b", bar, nil}}, "b", false},
+		{"all failed", []PriceSource{fakeSource{"a", nil, errors.New("outage")}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChain(nil, tt.sources...)
+			got, err := c.GetByDate("2021-02-26")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Chain.GetByDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got[0].Source != tt.wantSource {
+				t.Errorf("Chain.GetByDate() source = %v, want %v", got[0].Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestChainCancelled(t *testing.T) {
+	bar := []HistoricalPrice{{Code: 5, Date: "2021-02-26", Close: 44.4}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewChain(nil, fakeSource{"a", bar, nil})
+	_, err := c.GetByDateContext(ctx, "2021-02-26")
+	if err != context.Canceled {
+		t.Errorf("Chain.GetByDateContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,211 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+// Corporate action types
+const (
+	ActionDividend = "Dividend"
+	ActionSplit    = "Split" // also for consolidation, with ratio < 1
+	ActionBonus    = "Bonus"
+	ActionRights   = "Rights"
+)
+
+var (
+	splitRegex  = regexp.MustCompile(`(\d+)\s*into\s*(\d+)`)         // e.g. Sub-division: 1 into 4
+	issueRegex  = regexp.MustCompile(`(\d+)\s*for\s*(\d+)`)          // e.g. Bonus Issue: 1 for 10
+	amountRegex = regexp.MustCompile(`([A-Z]{3})\s*(\d+(?:\.\d+)?)`) // e.g. D:HKD 0.1500
+)
+
+// currencyCodes maps the currencies quoted by aastock to ISO 4217, e.g. RMB to CNY
+var currencyCodes = map[string]string{
+	"RMB": "CNY",
+}
+
+// CorporateAction is a dividend, split, bonus or rights issue of a stock
+type CorporateAction struct {
+	Code        string
+	ExDate      string
+	Type        string  // Dividend, Split, Bonus or Rights
+	Cash        float64 // cash dividend per share
+	Currency    string  // currency of the cash dividend or the subscription price
+	Ratio       float64 // shares after / shares before, e.g. 1.1 for a 1 for 10 bonus issue
+	Price       float64 // subscription price of the rights issue
+	Description string  // original particular, e.g. D:HKD 0.1500
+}
+
+// GetCorporateActions gets the dividend history of a stock from aastock
+func GetCorporateActions(code int) ([]CorporateAction, error) {
+	return GetCorporateActionsContext(context.Background(), code)
+}
+
+// GetCorporateActionsContext gets the dividend history of a stock from aastock
+func GetCorporateActionsContext(ctx context.Context, code int) ([]CorporateAction, error) {
+	var results []CorporateAction
+
+	codeF := fmt.Sprintf("%05d", code)
+	link := client.URL(web.AAStocks, fmt.Sprintf("/en/stocks/analysis/dividend.aspx?symbol=%s", codeF))
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return results, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return results, err
+	}
+
+	// Announce Date | Year Ended | Event | Particular | Type | Ex-Date | Book Close Date | Payable Date
+	doc.Find("table tr").Each(func(i int, tr *goquery.Selection) {
+		var values []string
+		tr.Find("td").Each(func(j int, td *goquery.Selection) {
+			values = append(values, strings.TrimSpace(td.Text()))
+		})
+		if len(values) != 8 {
+			return
+		}
+
+		exDate := strings.ReplaceAll(values[5], "/", "-") // e.g. 2021/03/08 -> 2021-03-08
+		if len(exDate) != 10 {
+			return // header or no ex-date
+		}
+
+		rec, ok := parseParticular(values[3])
+		if !ok {
+			return
+		}
+		rec.Code = codeF
+		rec.ExDate = exDate
+		results = append(results, rec)
+	})
+	return results, nil
+}
+
+// InsertCorporateAction inserts to the corporate_action table
+func InsertCorporateAction(data []CorporateAction) error {
+	return InsertCorporateActionContext(context.Background(), data)
+}
+
+// InsertCorporateActionContext inserts to the corporate_action table
+func InsertCorporateActionContext(ctx context.Context, data []CorporateAction) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("corporate_action", "code", "exdate", "type", "cash", "currency", "ratio", "price", "description"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Code, model.ExDate, model.Type, model.Cash, model.Currency, model.Ratio, model.Price, model.Description)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// parseParticular parses the particular of the dividend history, e.g.
+// D:HKD 0.1500, Sub-division: 1 into 4, Bonus Issue: 1 for 10, Rights Issue: 1 for 2 @HKD 2.50
+func parseParticular(s string) (CorporateAction, bool) {
+	rec := CorporateAction{
+		Ratio:       1,
+		Description: s,
+	}
+	lower := strings.ToLower(s)
+
+	switch {
+	case strings.Contains(lower, "sub-division") || strings.Contains(lower, "split") || strings.Contains(lower, "consolidation"):
+		m := splitRegex.FindStringSubmatch(s)
+		if m == nil {
+			return rec, false
+		}
+		before, _ := strconv.ParseFloat(m[1], 64)
+		after, _ := strconv.ParseFloat(m[2], 64)
+		if before == 0 {
+			return rec, false
+		}
+		rec.Type = ActionSplit
+		rec.Ratio = after / before
+
+	case strings.Contains(lower, "bonus") || strings.Contains(lower, "rights"):
+		m := issueRegex.FindStringSubmatch(s)
+		if m == nil {
+			return rec, false
+		}
+		issued, _ := strconv.ParseFloat(m[1], 64)
+		held, _ := strconv.ParseFloat(m[2], 64)
+		if held == 0 {
+			return rec, false
+		}
+		rec.Type = ActionBonus
+		rec.Ratio = 1 + issued/held
+		if strings.Contains(lower, "rights") {
+			rec.Type = ActionRights
+			if m := amountRegex.FindStringSubmatch(s); m != nil {
+				rec.Currency = isoCurrency(m[1])
+				rec.Price, _ = strconv.ParseFloat(m[2], 64)
+			}
+		}
+
+	default:
+		m := amountRegex.FindStringSubmatch(s)
+		if m == nil {
+			return rec, false // e.g. No Dividend
+		}
+		rec.Type = ActionDividend
+		rec.Currency = isoCurrency(m[1])
+		rec.Cash, _ = strconv.ParseFloat(m[2], 64)
+	}
+	return rec, true
+}
+
+// isoCurrency normalises the quoted currency to ISO 4217, e.g. RMB to CNY
+func isoCurrency(currency string) string {
+	if iso, ok := currencyCodes[currency]; ok {
+		return iso
+	}
+	return currency
+}
@@ -0,0 +1,36 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseParticular(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		want   CorporateAction
+		wantOk bool
+	}{
+		{"dividend", "D:HKD 0.1500", CorporateAction{Type: ActionDividend, Cash: 0.15, Currency: "HKD", Ratio: 1, Description: "D:HKD 0.1500"}, true},
+		{"rmb dividend", "D:RMB 0.3", CorporateAction{Type: ActionDividend, Cash: 0.3, Currency: "CNY", Ratio: 1, Description: "D:RMB 0.3"}, true},
+		{"special dividend", "Sp:USD 0.2", CorporateAction{Type: ActionDividend, Cash: 0.2, Currency: "USD", Ratio: 1, Description: "Sp:USD 0.2"}, true},
+		{"split", "Sub-division: 1 into 4", CorporateAction{Type: ActionSplit, Ratio: 4, Description: "Sub-division: 1 into 4"}, true},
+		{"consolidation", "Consolidation: 10 into 1", CorporateAction{Type: ActionSplit, Ratio: 0.1, Description: "Consolidation: 10 into 1"}, true},
+		{"bonus", "Bonus Issue: 1 for 10", CorporateAction{Type: ActionBonus, Ratio: 1.1, Description: "Bonus Issue: 1 for 10"}, true},
+		{"rights", "Rights Issue: 1 for 2 @HKD 2.50", CorporateAction{Type: ActionRights, Ratio: 1.5, Currency: "HKD", Price: 2.5, Description: "Rights Issue: 1 for 2 @HKD 2.50"}, true},
+		{"no dividend", "No Dividend", CorporateAction{Ratio: 1, Description: "No Dividend"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseParticular(tt.s)
+			if ok != tt.wantOk {
+				t.Errorf("parseParticular() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseParticular() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package stock
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AdjustMode for the adjusted price series
+type AdjustMode int
+
+const (
+	PriceOnly   AdjustMode = iota // adjusts for splits, bonus and rights issues
+	TotalReturn                   // also adjusts for the cash dividends
+)
+
+// Converter converts an amount between currencies with the rates on a date, e.g. fx.Convert
+type Converter func(amount float64, from, to, date string) (float64, error)
+
+// ConvertActions converts the cash dividend and the subscription price into the trading currency of the stock,
+// e.g. the USD dividends of HSBC into HKD with the rates on the ex-date.
+// Actions unable to convert are kept in their own currency and skipped by AdjustFactors.
+func ConvertActions(actions []CorporateAction, convert Converter) []CorporateAction {
+	result := make([]CorporateAction, len(actions))
+	for i, a := range actions {
+		result[i] = a
+		if inTradingCurrency(a) {
+			continue
+		}
+		to := tradingCurrency(a.Code)
+		cash, err := convert(a.Cash, a.Currency, to, a.ExDate)
+		if err != nil {
+			fmt.Printf("Unable to convert the %s of %s on %s from %s - %v\n", a.Type, a.Code, a.ExDate, a.Currency, err)
+			continue
+		}
+		price, err := convert(a.Price, a.Currency, to, a.ExDate)
+		if err != nil {
+			fmt.Printf("Unable to convert the %s of %s on %s from %s - %v\n", a.Type, a.Code, a.ExDate, a.Currency, err)
+			continue
+		}
+		a.Cash, a.Price, a.Currency = cash, price, to
+		result[i] = a
+	}
+	return result
+}
+
+// AdjustFactors derives the backward adjustment factor of each date from the corporate actions.
+// Dates are in YYYY-MM-DD format, closes are the unadjusted close on the dates, in any order.
+// Multiply the prices on a date by its factor to get the adjusted prices.
+// Dividends and rights not in the trading currency are skipped, see ConvertActions.
+func AdjustFactors(actions []CorporateAction, dates []string, closes []float64, mode AdjustMode) []float64 {
+	factors := make([]float64, len(dates))
+	for i := range factors {
+		factors[i] = 1
+	}
+
+	for _, a := range actions {
+		prevClose := previousClose(a.ExDate, dates, closes)
+		if (a.Type == ActionDividend || a.Type == ActionRights) && !inTradingCurrency(a) {
+			fmt.Printf("Skip the %s of %s on %s, %s is not the trading currency\n", a.Type, a.Code, a.ExDate, a.Currency)
+			continue
+		}
+
+		var f float64
+		switch a.Type {
+		case ActionSplit, ActionBonus:
+			if a.Ratio <= 0 {
+				continue
+			}
+			f = 1 / a.Ratio
+		case ActionRights:
+			if a.Ratio <= 0 || prevClose == 0 {
+				continue
+			}
+			terp := (prevClose + (a.Ratio-1)*a.Price) / a.Ratio // theoretical ex-rights price
+			f = terp / prevClose
+		case ActionDividend:
+			if mode != TotalReturn || prevClose == 0 {
+				continue
+			}
+			f = 1 - a.Cash/prevClose
+		}
+		if f <= 0 || f == 1 {
+			continue
+		}
+
+		for i, d := range dates {
+			if d < a.ExDate {
+				factors[i] *= f
+			}
+		}
+	}
+	return factors
+}
+
+// previousClose finds the close on the latest date before the ex-date
+func previousClose(exDate string, dates []string, closes []float64) float64 {
+	var (
+		latest string
+		close  float64
+	)
+	for i, d := range dates {
+		if d < exDate && d > latest && i < len(closes) {
+			latest = d
+			close = closes[i]
+		}
+	}
+	return close
+}
+
+// tradingCurrency derives the trading currency from the code, empty if unknown
+func tradingCurrency(code string) string {
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return ""
+	}
+	return Classify(n, "").Currency
+}
+
+// inTradingCurrency checks the cash and price of the action are in the trading currency, true if either is unknown
+func inTradingCurrency(a CorporateAction) bool {
+	to := tradingCurrency(a.Code)
+	return a.Currency == "" || to == "" || a.Currency == to
+}
@@ -0,0 +1,69 @@
+package stock
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestAdjustFactors(t *testing.T) {
+	dates := []string{"2021-03-03", "2021-03-02", "2021-03-01"}
+	closes := []float64{10, 40, 40}
+
+	tests := []struct {
+		name    string
+		actions []CorporateAction
+		mode    AdjustMode
+		want    []float64
+	}{
+		{"no actions", nil, TotalReturn, []float64{1, 1, 1}},
+		{"split", []CorporateAction{{ExDate: "2021-03-03", Type: ActionSplit, Ratio: 4}}, PriceOnly, []float64{1, 0.25, 0.25}},
+		{"dividend price only", []CorporateAction{{ExDate: "2021-03-02", Type: ActionDividend, Cash: 4}}, PriceOnly, []float64{1, 1, 1}},
+		{"dividend total return", []CorporateAction{{ExDate: "2021-03-02", Type: ActionDividend, Cash: 4}}, TotalReturn, []float64{1, 1, 0.9}},
+		{"rights", []CorporateAction{{ExDate: "2021-03-02", Type: ActionRights, Ratio: 2, Price: 20}}, PriceOnly, []float64{1, 1, 0.75}},
+		{"hkd dividend", []CorporateAction{{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 4, Currency: "HKD"}}, TotalReturn, []float64{1, 1, 0.9}},
+		{"usd dividend skipped", []CorporateAction{{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 0.5, Currency: "USD"}}, TotalReturn, []float64{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AdjustFactors(tt.actions, dates, closes, tt.mode)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("AdjustFactors() = %v, want %v", got, tt.want)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestConvertActions(t *testing.T) {
+	usdhkd := func(amount float64, from, to, date string) (float64, error) {
+		if from != "USD" || to != "HKD" {
+			return 0, fmt.Errorf("no fx rate - %s", from)
+		}
+		return amount * 7.75, nil
+	}
+	actions := []CorporateAction{
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 0.1, Currency: "USD"},
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 1, Currency: "HKD"},
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 1, Currency: "GBP"},
+	}
+	want := []CorporateAction{
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 0.775, Currency: "HKD"},
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 1, Currency: "HKD"},
+		{Code: "00005", ExDate: "2021-03-02", Type: ActionDividend, Cash: 1, Currency: "GBP"}, // unable to convert
+	}
+	got := ConvertActions(actions, usdhkd)
+	for i := range want {
+		if math.Abs(got[i].Cash-want[i].Cash) > 1e-9 || got[i].Currency != want[i].Currency {
+			t.Errorf("ConvertActions() = %v, want %v", got[i], want[i])
+		}
+	}
+
+	// the converted usd dividend of hsbc adjusts the total return in hkd
+	factors := AdjustFactors(got[:1], []string{"2021-03-02", "2021-03-01"}, []float64{38.75, 38.75}, TotalReturn)
+	if math.Abs(factors[1]-0.98) > 1e-9 {
+		t.Errorf("AdjustFactors() = %v, want [1 0.98]", factors)
+	}
+}
@@ -0,0 +1,247 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+// Headline categories of the announcements
+const (
+	CategoryResults    = "results"
+	CategoryDividend   = "dividend"
+	CategoryConnected  = "connected transaction"
+	CategoryNotifiable = "notifiable transaction"
+	CategoryMeeting    = "meeting"
+	CategoryCapital    = "capital"
+	CategoryDirectors  = "directors"
+	CategoryCircular   = "circular"
+	CategoryOther      = "other"
+)
+
+// categoryKeywords maps the hkexnews headline keywords to the categories, checked in order
+var categoryKeywords = []struct {
+	keyword  string
+	category string
+}{
+	{"poll results", CategoryMeeting}, // e.g. Poll Results of Annual General Meeting
+	{"voting by poll", CategoryMeeting},
+	{"final results", CategoryResults},
+	{"interim results", CategoryResults},
+	{"quarterly results", CategoryResults},
+	{"annual results", CategoryResults},
+	{"results announcement", CategoryResults},
+	{"dividend", CategoryDividend},
+	{"connected transaction", CategoryConnected},
+	{"discloseable transaction", CategoryNotifiable},
+	{"major transaction", CategoryNotifiable},
+	{"very substantial", CategoryNotifiable},
+	{"meeting", CategoryMeeting},
+	{"share buyback", CategoryCapital},
+	{"issue of shares", CategoryCapital},
+	{"movements in securities", CategoryCapital},
+	{"rights issue", CategoryCapital},
+	{"director", CategoryDirectors},
+	{"circulars", CategoryCircular},
+}
+
+var stockIDRegex = regexp.MustCompile(`"stockId":(\d+),"code":"(\d{5})"`)
+
+// announcementRows is the max no of announcements of a single title search
+const announcementRows = 1000
+
+// Announcement is an announcement from hkexnews
+type Announcement struct {
+	Code        string
+	ReleaseTime time.Time
+	Category    string // e.g. results, dividend, connected transaction
+	Headline    string // hkexnews headline, e.g. Announcements and Notices - [Final Results]
+	Title       string
+	URL         string
+}
+
+// titleSearchResult is a single record of the hkexnews title search
+type titleSearchResult struct {
+	DateTime  string `json:"DATE_TIME"` // e.g. 26/02/2021 17:02
+	StockCode string `json:"STOCK_CODE"`
+	LongText  string `json:"LONG_TEXT"`
+	Title     string `json:"TITLE"`
+	FileLink  string `json:"FILE_LINK"`
+}
+
+// GetAnnouncements gets the announcements of a stock released between from and to (inclusive)
+func GetAnnouncements(code int, from, to string) ([]Announcement, error) {
+	return GetAnnouncementsContext(context.Background(), code, from, to)
+}
+
+// GetAnnouncementsContext gets the announcements of a stock released between from and to (inclusive).
+// Date ranges with more than 1000 announcements return an error, split the range instead.
+func GetAnnouncementsContext(ctx context.Context, code int, from, to string) ([]Announcement, error) {
+	var results []Announcement
+
+	codeF := fmt.Sprintf("%05d", code)
+	stockID, err := getStockID(ctx, codeF)
+	if err != nil {
+		return results, err
+	}
+
+	link := client.URL(web.HKEXNewsSearch, fmt.Sprintf("/search/titleSearchServlet.do?sortDir=0&sortByOptions=DateTime&category=0&market=SEHK&stockId=%d&documentType=-1&fromDate=%s&toDate=%s&title=&searchType=0&t1code=-2&t2Gcode=-2&t2code=-2&rowRange=%d&lang=E",
+		stockID, strings.ReplaceAll(from, "-", ""), strings.ReplaceAll(to, "-", ""), announcementRows))
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return results, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return results, err
+	}
+	return parseAnnouncements(codeF, body)
+}
+
+// Categorise maps the hkexnews headline to a category
+func Categorise(headline string) string {
+	lower := strings.ToLower(headline)
+	for _, c := range categoryKeywords {
+		if strings.Contains(lower, c.keyword) {
+			return c.category
+		}
+	}
+	return CategoryOther
+}
+
+// InsertAnnouncement inserts to the announcement table
+func InsertAnnouncement(data []Announcement) error {
+	return InsertAnnouncementContext(context.Background(), data)
+}
+
+// InsertAnnouncementContext inserts to the announcement table
+func InsertAnnouncementContext(ctx context.Context, data []Announcement) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("announcement", "code", "releasetime", "category", "headline", "title", "url"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Code, model.ReleaseTime, model.Category, model.Headline, model.Title, model.URL)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// parseAnnouncements parses the title search response, with the records json encoded as a string in result
+func parseAnnouncements(codeF string, body []byte) ([]Announcement, error) {
+	var results []Announcement
+
+	var response struct {
+		Result     string `json:"result"`
+		HasNextRow bool   `json:"hasNextRow"`
+		RecordCnt  int    `json:"recordCnt"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return results, err
+	}
+	if response.HasNextRow {
+		return results, fmt.Errorf("too many announcements - %s - %d, narrow the date range", codeF, response.RecordCnt)
+	}
+	if response.Result == "" || response.Result == "null" {
+		return results, nil // no announcements
+	}
+
+	var records []titleSearchResult
+	if err := json.Unmarshal([]byte(response.Result), &records); err != nil {
+		return results, err
+	}
+
+	loc, _ := time.LoadLocation("Asia/Hong_Kong")
+	if loc == nil {
+		loc = time.FixedZone("HKT", 8*60*60)
+	}
+	for _, r := range records {
+		releaseTime, err := time.ParseInLocation("02/01/2006 15:04", r.DateTime, loc)
+		if err != nil {
+			continue
+		}
+		headline := strings.TrimSpace(r.LongText)
+		rec := Announcement{
+			Code:        codeF,
+			ReleaseTime: releaseTime,
+			Category:    Categorise(headline),
+			Headline:    headline,
+			Title:       strings.TrimSpace(r.Title),
+			URL:         client.URL(web.HKEXNewsSearch, r.FileLink),
+		}
+		results = append(results, rec)
+	}
+	return results, nil
+}
+
+// getStockID looks up the internal hkexnews stock id of a code
+func getStockID(ctx context.Context, codeF string) (int, error) {
+	link := client.URL(web.HKEXNewsSearch, fmt.Sprintf("/search/prefix.do?callback=callback&lang=EN&type=A&name=%s&market=SEHK", codeF))
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return 0, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	// e.g. callback({"more":"1","stockInfo":[{"stockId":7609,"code":"00005","name":"HSBC HOLDINGS"}]});
+	for _, m := range stockIDRegex.FindAllStringSubmatch(string(body), -1) {
+		if m[2] == codeF {
+			var id int
+			fmt.Sscanf(m[1], "%d", &id)
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("stock id not found - %s", codeF)
+}
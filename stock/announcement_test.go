@@ -0,0 +1,117 @@
+package stock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestCategorise(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"Announcements and Notices - [Final Results]", CategoryResults},
+		{"Announcements and Notices - [Interim Results]", CategoryResults},
+		{"Announcements and Notices - [Quarterly Results]", CategoryResults},
+		{"Announcements and Notices - [Final Results / Dividend or Distribution]", CategoryResults},
+		{"Announcements and Notices - [Dividend or Distribution]", CategoryDividend},
+		{"Announcements and Notices - [Poll Results of Annual General Meeting]", CategoryMeeting},
+		{"Announcements and Notices - [Results of Voting by Poll / Poll Results of Extraordinary General Meeting]", CategoryMeeting},
+		{"Announcements and Notices - [Results of Voting by Poll]", CategoryMeeting},
+		{"Announcements and Notices - [Date of Board Meeting]", CategoryMeeting},
+		{"Announcements and Notices - [Connected Transaction]", CategoryConnected},
+		{"Announcements and Notices - [Discloseable Transaction]", CategoryNotifiable},
+		{"Monthly Returns - [Movements in Securities]", CategoryCapital},
+		{"Announcements and Notices - [Change in Directors or of Important Executive Functions or Responsibilities]", CategoryDirectors},
+		{"Circulars - [Explanatory Statement for Repurchase of Shares]", CategoryCircular},
+		{"Financial Statements/ESG Information - [Annual Report]", CategoryOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.headline, func(t *testing.T) {
+			if got := Categorise(tt.headline); got != tt.want {
+				t.Errorf("Categorise() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAnnouncements(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetAnnouncements(5, "2021-02-22", "2021-02-26")
+	if err != nil {
+		t.Fatalf("GetAnnouncements() error = %v", err)
+	}
+	if len(got) != 2 { // the record without a release time is skipped
+		t.Fatalf("GetAnnouncements() got %d records, want 2", len(got))
+	}
+
+	hkt := time.FixedZone("HKT", 8*60*60)
+	want := []struct {
+		releaseTime time.Time
+		category    string
+		headline    string
+		title       string
+		path        string
+	}{
+		{time.Date(2021, 2, 23, 12, 1, 0, 0, hkt), CategoryResults, "Announcements and Notices - [Final Results / Dividend or Distribution]", "2020 Annual Results", "/listedco/listconews/sehk/2021/0223/2021022300178.pdf"},
+		{time.Date(2021, 2, 26, 17, 2, 0, 0, hkt), CategoryMeeting, "Announcements and Notices - [Poll Results of Annual General Meeting]", "Poll Results of Annual General Meeting", "/listedco/listconews/sehk/2021/0226/2021022601234.pdf"},
+	}
+	for i, w := range want {
+		a := got[i]
+		if a.Code != "00005" {
+			t.Errorf("GetAnnouncements() code = %v, want 00005", a.Code)
+		}
+		if !a.ReleaseTime.Equal(w.releaseTime) {
+			t.Errorf("GetAnnouncements() release time = %v, want %v", a.ReleaseTime, w.releaseTime)
+		}
+		if a.Category != w.category || a.Headline != w.headline || a.Title != w.title {
+			t.Errorf("GetAnnouncements() = %v / %v / %v, want %v / %v / %v", a.Category, a.Headline, a.Title, w.category, w.headline, w.title)
+		}
+		if link := client.URL(web.HKEXNewsSearch, w.path); a.URL != link {
+			t.Errorf("GetAnnouncements() url = %v, want %v", a.URL, link)
+		}
+	}
+}
+
+func Test_getStockID(t *testing.T) {
+	newTestServer(t)
+
+	got, err := getStockID(context.Background(), "00005")
+	if err != nil {
+		t.Fatalf("getStockID() error = %v", err)
+	}
+	if got != 7609 {
+		t.Errorf("getStockID() = %v, want 7609", got)
+	}
+	if _, err := getStockID(context.Background(), "00700"); err == nil {
+		t.Errorf("getStockID() error = nil, want not found")
+	}
+}
+
+func Test_parseAnnouncements(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{"no announcements", `{"result":"null","hasNextRow":false,"recordCnt":0}`, 0, false},
+		{"more rows", `{"result":"[]","hasNextRow":true,"rowRange":1000,"loadedRecord":1000,"recordCnt":1250}`, 0, true},
+		{"invalid", `{"result":"[{"}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAnnouncements("00005", []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAnnouncements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("parseAnnouncements() got %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/lib/pq"
+)
+
+// BankingRatio from aastock, only populated for the bank industries
+type BankingRatio struct {
+	Date          string
+	Sector        string
+	Industry      string
+	Code          string
+	Close         float64
+	NIM           float64 // Net Interest Margin (%)
+	CostToIncome  float64 // (%)
+	LoanToDeposit float64 // (%)
+	CAR           float64 // Capital Adequacy Ratio (%)
+}
+
+// GetIndustryBankingRatio gets the banking ratios of the industries which populate the tab
+func GetIndustryBankingRatio(date string) ([]BankingRatio, error) {
+	return GetIndustryBankingRatioContext(context.Background(), date)
+}
+
+// GetIndustryBankingRatioContext gets the banking ratios of the industries which populate the tab
+func GetIndustryBankingRatioContext(ctx context.Context, date string) ([]BankingRatio, error) {
+	var results []BankingRatio
+
+	exist, err := db.RecordExistsContext(ctx, "industry_banking", date)
+	if err != nil {
+		return results, err
+	}
+	if exist {
+		return results, fmt.Errorf("records exists in db - %s", date)
+	}
+
+	links, err := getIndustryLinks(ctx, date, 5) // check dates
+	if err != nil {
+		return results, err
+	}
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		rec, err := getBankingRatio(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, rec...)
+	}
+	return results, nil
+}
+
+// InsertBankingRatio inserts to the industry_banking table
+func InsertBankingRatio(data []BankingRatio) error {
+	return InsertBankingRatioContext(context.Background(), data)
+}
+
+// InsertBankingRatioContext inserts to the industry_banking table
+func InsertBankingRatioContext(ctx context.Context, data []BankingRatio) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry_banking", "date", "sector", "industry", "code", "close", "nim", "costtoincome", "loantodeposit", "car"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.Code, model.Close, model.NIM, model.CostToIncome, model.LoanToDeposit, model.CAR)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getBankingRatio gets the banking ratio tab of a single industry.
+// Non-bank industries show a blank tab, which returns no records without error.
+func getBankingRatio(ctx context.Context, date, link string) ([]BankingRatio, error) {
+	var results []BankingRatio
+
+	sector, industry, rows, err := getIndustryTable(ctx, link)
+	if err != nil {
+		return results, err
+	}
+
+	// Code | Name | Close | Chg | Chg% | NIM | Cost/Income | Loan/Deposit | CAR
+	for _, values := range rows {
+		if len(values) == 9 && !isBlank(values[5:]) {
+			close, _ := util.ParseF(values[2])
+			nim, _ := util.ParseF(values[5])
+			costToIncome, _ := util.ParseF(values[6])
+			loanToDeposit, _ := util.ParseF(values[7])
+			car, _ := util.ParseF(values[8])
+
+			rec := BankingRatio{
+				Date:          date,
+				Sector:        sector,
+				Industry:      industry,
+				Code:          values[0],
+				Close:         close,
+				NIM:           nim,
+				CostToIncome:  costToIncome,
+				LoanToDeposit: loanToDeposit,
+				CAR:           car,
+			}
+			results = append(results, rec)
+		}
+	}
+	if len(results) == 0 {
+		fmt.Printf("Skipping blank banking ratio - [%s] - [%s]\n", sector, industry)
+	}
+	return results, nil
+}
+
+// isBlank checks if all the values are empty, e.g. "", "-" or "N/A"
+func isBlank(values []string) bool {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && v != "-" && v != "N/A" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package stock
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestGetBankingRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		industry string
+		want     []BankingRatio
+	}{
+		{
+			name:     "banks",
+			industry: "4010",
+			want: []BankingRatio{
+				{Date: "2021-02-26", Sector: "Financials", Industry: "Banks", Code: "00005", Close: 45.15, NIM: 1.32, CostToIncome: 68.3, LoanToDeposit: 59.1, CAR: 20.1},
+				{Date: "2021-02-26", Sector: "Financials", Industry: "Banks", Code: "00011", Close: 146.8, NIM: 1.61, CostToIncome: 35.6, CAR: 20.4},
+			},
+		},
+		{
+			name:     "blank for non banks",
+			industry: "2010",
+			want:     nil,
+		},
+	}
+	newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := client.URL(web.AAStocks, "/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol="+tt.industry+"&t=5&s=&o=&p=")
+			got, err := getBankingRatio(context.Background(), "2021-02-26", link)
+			if err != nil {
+				t.Fatalf("getBankingRatio() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getBankingRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
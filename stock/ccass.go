@@ -0,0 +1,164 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+// CCASSHolding is the shareholding of a CCASS participant
+type CCASSHolding struct {
+	Date          string
+	Code          string
+	ParticipantID string // e.g. C00019, empty for non-CCASS participants
+	Name          string
+	Shareholding  int
+	Pct           float64 // % of the total number of issued shares
+}
+
+// GetCCASSHoldings gets the participants shareholding of a stock from the hkexnews CCASS search
+func GetCCASSHoldings(code int, date string) ([]CCASSHolding, error) {
+	return GetCCASSHoldingsContext(context.Background(), code, date)
+}
+
+// GetCCASSHoldingsContext gets the participants shareholding of a stock from the hkexnews CCASS search
+func GetCCASSHoldingsContext(ctx context.Context, code int, date string) ([]CCASSHolding, error) {
+	var results []CCASSHolding
+
+	codeF := fmt.Sprintf("%05d", code)
+	link := client.URL(web.HKEXNewsSDW, "/sdw/search/searchsdw.aspx")
+
+	form := url.Values{}
+	form.Set("txtShareholdingDate", strings.ReplaceAll(date, "-", "/")) // e.g. 2021/02/26
+	form.Set("txtStockCode", codeF)
+
+	doc, err := searchSDW(ctx, link, form)
+	if err != nil {
+		return results, err
+	}
+
+	// Participant ID | Name | Address | Shareholding | % of issued shares
+	doc.Find("#pnlResultNormal table tbody tr").Each(func(i int, tr *goquery.Selection) {
+		cell := func(class string) string {
+			return strings.TrimSpace(tr.Find(fmt.Sprintf("td.%s div.mobile-list-body", class)).Text())
+		}
+		shareholding, err := util.ParseN(cell("col-shareholding"))
+		if err != nil {
+			return
+		}
+		pct, _ := util.ParseF(cell("col-shareholding-percent"))
+
+		rec := CCASSHolding{
+			Date:          date,
+			Code:          codeF,
+			ParticipantID: cell("col-participant-id"),
+			Name:          cell("col-participant-name"),
+			Shareholding:  shareholding,
+			Pct:           pct,
+		}
+		results = append(results, rec)
+	})
+	if len(results) == 0 {
+		return results, fmt.Errorf("no CCASS holdings - %s - %s", codeF, date)
+	}
+	return results, nil
+}
+
+// searchSDW submits the search form on the hkexnews shareholding disclosure pages, e.g. CCASS
+func searchSDW(ctx context.Context, link string, fields url.Values) (*goquery.Document, error) {
+
+	// The search is an asp.net form, get the hidden states first
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	doc.Find("input[type=hidden]").Each(func(i int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		value, _ := s.Attr("value")
+		if name != "" {
+			form.Set(name, value)
+		}
+	})
+	form.Set("__EVENTTARGET", "btnSearch")
+	for k := range fields {
+		form.Set(k, fields.Get(k))
+	}
+
+	res, err = client.PostFormContext(ctx, link, form)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	return goquery.NewDocumentFromReader(res.Body)
+}
+
+// InsertCCASSHoldings inserts to the ccass_holding table
+func InsertCCASSHoldings(data []CCASSHolding) error {
+	return InsertCCASSHoldingsContext(context.Background(), data)
+}
+
+// InsertCCASSHoldingsContext inserts to the ccass_holding table
+func InsertCCASSHoldingsContext(ctx context.Context, data []CCASSHolding) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("ccass_holding", "date", "code", "participantid", "name", "shareholding", "pct"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Code, model.ParticipantID, model.Name, model.Shareholding, model.Pct)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
@@ -0,0 +1,38 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetCCASSHoldings(t *testing.T) {
+	server := newTestServer(t)
+
+	got, err := GetCCASSHoldings(5, "2021-02-26")
+	if err != nil {
+		t.Fatalf("GetCCASSHoldings() error = %v", err)
+	}
+
+	// the search posts back the hidden states with the search fields
+	form := server.form("/sdw/search/searchsdw.aspx")
+	posted := map[string]string{
+		"__VIEWSTATE":         "dDwtMTk4MDQ=",
+		"__EVENTTARGET":       "btnSearch",
+		"txtShareholdingDate": "2021/02/26",
+		"txtStockCode":        "00005",
+	}
+	for k, v := range posted {
+		if form.Get(k) != v {
+			t.Errorf("posted %s = %q, want %q", k, form.Get(k), v)
+		}
+	}
+
+	want := []CCASSHolding{
+		{Date: "2021-02-26", Code: "00005", ParticipantID: "C00019", Name: "THE HONGKONG AND SHANGHAI BANKING", Shareholding: 5123456789, Pct: 25.17},
+		{Date: "2021-02-26", Code: "00005", ParticipantID: "A00003", Name: "CHINA SECURITIES DEPOSITORY AND CLEARING", Shareholding: 1672316590, Pct: 8.21},
+		{Date: "2021-02-26", Code: "00005", ParticipantID: "", Name: "HSBC HOLDINGS PLC UK REGISTER", Shareholding: 9876543210, Pct: 48.53}, // outside CCASS
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCCASSHoldings() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,15 @@
+package stock
+
+import (
+	"github.com/billylkc/stocklib/web"
+)
+
+// client is the shared http client of the scrapers, also passed down to the util website checks
+var client = web.New()
+
+// SetClient sets the http client of all the scrapers in the package, including the data ready checks on aastocks.
+// The company directory is reloaded with the new client on the next lookup.
+func SetClient(c *web.Client) {
+	client = c
+	resetDirectory()
+}
@@ -0,0 +1,295 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+// Company master data
+type Company struct {
+	Code        string // code in 5 digit format, e.g. 00005
+	Name        string // English name
+	NameZH      string // Chinese name
+	ISIN        string
+	ListingDate string // in YYYY-MM-DD format, from the company profile on lookup, see FillListingDates
+	BoardLot    int
+	Currency    string // trading currency, e.g. HKD
+}
+
+// directory is the in-memory company directory, loaded once
+var directory struct {
+	sync.Mutex
+	list   []Company
+	byCode map[string]int // index of the list
+}
+
+// resetDirectory clears the company directory, e.g. after changing the client
+func resetDirectory() {
+	directory.Lock()
+	directory.list = nil
+	directory.byCode = nil
+	directory.Unlock()
+}
+
+// LoadCompanies loads the company directory once and returns all the companies.
+// Failed loads are retried on the next call.
+func LoadCompanies() ([]Company, error) {
+	return LoadCompaniesContext(context.Background())
+}
+
+// LoadCompaniesContext loads the company directory once, with the context for the first load
+func LoadCompaniesContext(ctx context.Context) ([]Company, error) {
+	directory.Lock()
+	defer directory.Unlock()
+
+	if directory.list != nil {
+		return directory.list, nil
+	}
+	list, err := getCompanies(ctx)
+	if err != nil {
+		return list, err
+	}
+	directory.list = list
+	directory.byCode = make(map[string]int)
+	for i, c := range list {
+		directory.byCode[c.Code] = i
+	}
+	return directory.list, nil
+}
+
+// LookupCompany looks up a company by code from the directory, with the listing date from the company profile
+func LookupCompany(code int) (Company, error) {
+	return LookupCompanyContext(context.Background(), code)
+}
+
+// LookupCompanyContext looks up a company by code from the directory, with the listing date from the company profile.
+// The listing date is kept in the directory, and left empty if the profile is unavailable.
+func LookupCompanyContext(ctx context.Context, code int) (Company, error) {
+	var result Company
+
+	if _, err := LoadCompaniesContext(ctx); err != nil {
+		return result, err
+	}
+	codeF := fmt.Sprintf("%05d", code)
+	directory.Lock()
+	i, ok := directory.byCode[codeF]
+	if ok {
+		result = directory.list[i]
+	}
+	directory.Unlock()
+	if !ok {
+		return result, fmt.Errorf("company not found - %s", codeF)
+	}
+
+	if result.ListingDate == "" {
+		listingDate, err := GetListingDateContext(ctx, code)
+		if err != nil {
+			fmt.Printf("Unable to get the listing date of %s - %v\n", codeF, err)
+			return result, nil
+		}
+		result.ListingDate = listingDate
+		directory.Lock()
+		if j, ok := directory.byCode[codeF]; ok { // reloaded meanwhile
+			directory.list[j].ListingDate = listingDate
+		}
+		directory.Unlock()
+	}
+	return result, nil
+}
+
+// FillListingDates fills the listing dates of the companies from the company profiles, one request per company,
+// e.g. before InsertCompany. Companies without a profile are left empty.
+func FillListingDates(data []Company) ([]Company, error) {
+	return FillListingDatesContext(context.Background(), data)
+}
+
+// FillListingDatesContext fills the listing dates of the companies from the company profiles, stopping when the context is done
+func FillListingDatesContext(ctx context.Context, data []Company) ([]Company, error) {
+	result := make([]Company, len(data))
+	copy(result, data)
+	for i, c := range result {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if c.ListingDate != "" {
+			continue
+		}
+		var code int
+		fmt.Sscanf(c.Code, "%d", &code)
+		listingDate, err := GetListingDateContext(ctx, code)
+		if err != nil {
+			fmt.Printf("Unable to get the listing date of %s - %v\n", c.Code, err)
+			continue
+		}
+		result[i].ListingDate = listingDate
+	}
+	return result, nil
+}
+
+// GetListingDate gets the listing date of a stock from the aastock company profile, in YYYY-MM-DD format
+func GetListingDate(code int) (string, error) {
+	return GetListingDateContext(context.Background(), code)
+}
+
+// GetListingDateContext gets the listing date of a stock from the aastock company profile, in YYYY-MM-DD format
+func GetListingDateContext(ctx context.Context, code int) (string, error) {
+	codeF := fmt.Sprintf("%05d", code)
+	link := client.URL(web.AAStocks, fmt.Sprintf("/en/stocks/analysis/company-fundamental/company-profile?symbol=%s", codeF))
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// Listing Date | 1991/07/29
+	var value string
+	doc.Find("tr").Each(func(i int, tr *goquery.Selection) {
+		tds := tr.Find("td")
+		if value == "" && tds.Length() >= 2 && strings.EqualFold(strings.TrimSpace(tds.First().Text()), "Listing Date") {
+			value = strings.TrimSpace(tds.Eq(1).Text())
+		}
+	})
+	if value == "" {
+		return "", fmt.Errorf("no listing date - %s", codeF)
+	}
+	d, err := time.Parse("2006/01/02", value)
+	if err != nil {
+		return "", err
+	}
+	return d.Format("2006-01-02"), nil
+}
+
+// SearchCompany searches the companies by English or Chinese name, case insensitive
+func SearchCompany(name string) ([]Company, error) {
+	return SearchCompanyContext(context.Background(), name)
+}
+
+// SearchCompanyContext searches the companies by English or Chinese name, case insensitive
+func SearchCompanyContext(ctx context.Context, name string) ([]Company, error) {
+	var result []Company
+
+	companies, err := LoadCompaniesContext(ctx)
+	if err != nil {
+		return result, err
+	}
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _, c := range companies {
+		if strings.Contains(strings.ToUpper(c.Name), name) || strings.Contains(c.NameZH, name) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// InsertCompany inserts to the company table
+func InsertCompany(data []Company) error {
+	return InsertCompanyContext(context.Background(), data)
+}
+
+// InsertCompanyContext inserts to the company table
+func InsertCompanyContext(ctx context.Context, data []Company) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("company", "code", "name", "namezh", "isin", "listingdate", "boardlot", "currency"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		var listingDate interface{} // null if unknown
+		if model.ListingDate != "" {
+			listingDate = model.ListingDate
+		}
+		_, err := stmt.ExecContext(ctx, model.Code, model.Name, model.NameZH, model.ISIN, listingDate, model.BoardLot, model.Currency)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getCompanies merges the English and Chinese stock lists
+func getCompanies(ctx context.Context) ([]Company, error) {
+	var result []Company
+
+	companies, err := getStockList(ctx, english)
+	if err != nil {
+		return result, err
+	}
+	names, err := getStockList(ctx, chinese)
+	if err != nil {
+		return result, err
+	}
+	nameZH := make(map[string]string)
+	for _, c := range names {
+		nameZH[c.Code] = c.Name
+	}
+
+	// ISIN and board lot from the list of securities, if available
+	listed := make(map[string]Security)
+	securities, err := getListOfSecurities(ctx)
+	if err != nil {
+		fmt.Printf("Unable to get the list of securities - %v\n", err)
+	}
+	for _, s := range securities {
+		listed[s.CodeF] = s
+	}
+
+	for _, c := range companies {
+		var code int
+		fmt.Sscanf(c.Code, "%d", &code)
+
+		c.NameZH = nameZH[c.Code]
+		c.Currency = Classify(code, c.Name).Currency
+		if s, ok := listed[c.Code]; ok {
+			c.ISIN = s.ISIN
+			c.BoardLot = s.LotSize
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
@@ -0,0 +1,385 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+// ConnectTurnover is the daily Stock Connect turnover of a market, e.g. SSE Southbound
+type ConnectTurnover struct {
+	Date          string
+	Market        string  // e.g. SSE Northbound, SSE Southbound, SZSE Northbound, SZSE Southbound
+	TotalTurnover float64 // in millions, RMB for northbound, HKD for southbound
+	BuyTurnover   float64
+	SellTurnover  float64
+	TotalTrades   int
+	BuyTrades     int
+	SellTrades    int
+}
+
+// ConnectTopTraded is the top 10 traded stocks of a market
+type ConnectTopTraded struct {
+	Date          string
+	Market        string
+	Rank          int
+	Code          string // e.g. 00700 for southbound, 600519 for northbound
+	Name          string
+	BuyTurnover   int
+	SellTurnover  int
+	TotalTurnover int
+}
+
+// ConnectHolding is the southbound shareholding of a HK stock, held through ChinaClear
+type ConnectHolding struct {
+	Date         string
+	Code         string
+	Name         string
+	Shareholding int
+	Pct          float64 // % of the total number of issued shares
+}
+
+// connectTab is a market tab in the HKEX daily stat js
+type connectTab struct {
+	Market  string `json:"market"`
+	Date    string `json:"date"`
+	Content []struct {
+		Table struct {
+			Schema [][]string `json:"schema"`
+			Tr     []struct {
+				Td [][]string `json:"td"`
+			} `json:"tr"`
+		} `json:"table"`
+	} `json:"content"`
+}
+
+// GetConnectTurnover gets the daily turnover of all the Stock Connect markets
+func GetConnectTurnover(date string) ([]ConnectTurnover, error) {
+	return GetConnectTurnoverContext(context.Background(), date)
+}
+
+// GetConnectTurnoverContext gets the daily turnover of all the Stock Connect markets
+func GetConnectTurnoverContext(ctx context.Context, date string) ([]ConnectTurnover, error) {
+	var results []ConnectTurnover
+
+	tabs, err := getConnectTabs(ctx, date)
+	if err != nil {
+		return results, err
+	}
+
+	for _, tab := range tabs {
+		if len(tab.Content) == 0 {
+			continue
+		}
+
+		// Each row of the first table is a figure, named by the schema
+		table := tab.Content[0].Table
+		if len(table.Schema) == 0 {
+			continue
+		}
+		figures := make(map[string]string)
+		for i, name := range table.Schema[0] {
+			if i < len(table.Tr) && len(table.Tr[i].Td) > 0 && len(table.Tr[i].Td[0]) > 0 {
+				figures[name] = strings.ReplaceAll(table.Tr[i].Td[0][0], ",", "")
+			}
+		}
+
+		rec := ConnectTurnover{
+			Date:   date,
+			Market: tab.Market,
+		}
+		rec.TotalTurnover, _ = util.ParseF(figures["Total Turnover"])
+		rec.BuyTurnover, _ = util.ParseF(figures["Buy Turnover"])
+		rec.SellTurnover, _ = util.ParseF(figures["Sell Turnover"])
+		rec.TotalTrades, _ = util.ParseN(figures["Total Trade Count"])
+		rec.BuyTrades, _ = util.ParseN(figures["Buy Trade Count"])
+		rec.SellTrades, _ = util.ParseN(figures["Sell Trade Count"])
+		results = append(results, rec)
+	}
+	return results, nil
+}
+
+// GetConnectTopTraded gets the top 10 traded stocks of all the Stock Connect markets
+func GetConnectTopTraded(date string) ([]ConnectTopTraded, error) {
+	return GetConnectTopTradedContext(context.Background(), date)
+}
+
+// GetConnectTopTradedContext gets the top 10 traded stocks of all the Stock Connect markets
+func GetConnectTopTradedContext(ctx context.Context, date string) ([]ConnectTopTraded, error) {
+	var results []ConnectTopTraded
+
+	tabs, err := getConnectTabs(ctx, date)
+	if err != nil {
+		return results, err
+	}
+
+	for _, tab := range tabs {
+		if len(tab.Content) < 2 {
+			continue
+		}
+
+		// Rank | Stock Code | Stock Name | Buy Turnover | Sell Turnover | Total Turnover
+		for _, tr := range tab.Content[1].Table.Tr {
+			if len(tr.Td) == 0 || len(tr.Td[0]) != 6 {
+				continue
+			}
+			values := tr.Td[0]
+			rank, _ := util.ParseN(values[0])
+			buy, _ := util.ParseN(values[3])
+			sell, _ := util.ParseN(values[4])
+			total, _ := util.ParseN(values[5])
+
+			rec := ConnectTopTraded{
+				Date:          date,
+				Market:        tab.Market,
+				Rank:          rank,
+				Code:          strings.TrimSpace(values[1]),
+				Name:          strings.TrimSpace(values[2]),
+				BuyTurnover:   buy,
+				SellTurnover:  sell,
+				TotalTurnover: total,
+			}
+			results = append(results, rec)
+		}
+	}
+	return results, nil
+}
+
+// GetSouthboundHoldings gets the southbound shareholding of all the HK stocks from hkexnews
+func GetSouthboundHoldings(date string) ([]ConnectHolding, error) {
+	return GetSouthboundHoldingsContext(context.Background(), date)
+}
+
+// GetSouthboundHoldingsContext gets the southbound shareholding of all the HK stocks from hkexnews
+func GetSouthboundHoldingsContext(ctx context.Context, date string) ([]ConnectHolding, error) {
+	var results []ConnectHolding
+
+	link := client.URL(web.HKEXNewsSDW, "/sdw/search/mutualmarket.aspx?t=hk")
+
+	form := url.Values{}
+	form.Set("txtShareholdingDate", strings.ReplaceAll(date, "-", "/")) // e.g. 2021/02/26
+
+	doc, err := searchSDW(ctx, link, form)
+	if err != nil {
+		return results, err
+	}
+
+	// Stock Code | Name | Shareholding | % of issued shares
+	doc.Find("#mutualmarket-result tbody tr").Each(func(i int, tr *goquery.Selection) {
+		cell := func(class string) string {
+			return strings.TrimSpace(tr.Find(fmt.Sprintf("td.%s div.mobile-list-body", class)).Text())
+		}
+		code, err := util.ParseN(cell("col-stock-code"))
+		if err != nil {
+			return
+		}
+		shareholding, _ := util.ParseN(cell("col-shareholding"))
+		pct, _ := util.ParseF(cell("col-shareholding-percent"))
+
+		rec := ConnectHolding{
+			Date:         date,
+			Code:         fmt.Sprintf("%05d", code),
+			Name:         cell("col-stock-name"),
+			Shareholding: shareholding,
+			Pct:          pct,
+		}
+		results = append(results, rec)
+	})
+	if len(results) == 0 {
+		return results, fmt.Errorf("data not ready - %s", date)
+	}
+	return results, nil
+}
+
+// InsertConnectTurnover inserts to the connect_turnover table
+func InsertConnectTurnover(data []ConnectTurnover) error {
+	return InsertConnectTurnoverContext(context.Background(), data)
+}
+
+// InsertConnectTurnoverContext inserts to the connect_turnover table
+func InsertConnectTurnoverContext(ctx context.Context, data []ConnectTurnover) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("connect_turnover", "date", "market", "totalturnover", "buyturnover", "sellturnover", "totaltrades", "buytrades", "selltrades"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Market, model.TotalTurnover, model.BuyTurnover, model.SellTurnover, model.TotalTrades, model.BuyTrades, model.SellTrades)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// InsertConnectTopTraded inserts to the connect_top_traded table
+func InsertConnectTopTraded(data []ConnectTopTraded) error {
+	return InsertConnectTopTradedContext(context.Background(), data)
+}
+
+// InsertConnectTopTradedContext inserts to the connect_top_traded table
+func InsertConnectTopTradedContext(ctx context.Context, data []ConnectTopTraded) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("connect_top_traded", "date", "market", "rank", "code", "name", "buyturnover", "sellturnover", "totalturnover"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Market, model.Rank, model.Code, model.Name, model.BuyTurnover, model.SellTurnover, model.TotalTurnover)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// InsertConnectHolding inserts to the connect_holding table
+func InsertConnectHolding(data []ConnectHolding) error {
+	return InsertConnectHoldingContext(context.Background(), data)
+}
+
+// InsertConnectHoldingContext inserts to the connect_holding table
+func InsertConnectHoldingContext(ctx context.Context, data []ConnectHolding) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("connect_holding", "date", "code", "name", "shareholding", "pct"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Code, model.Name, model.Shareholding, model.Pct)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getConnectTabs gets the market tabs from the HKEX Stock Connect daily stat js
+func getConnectTabs(ctx context.Context, date string) ([]connectTab, error) {
+	var tabs []connectTab
+
+	d := strings.ReplaceAll(date, "-", "") // e.g. 20210226
+	link := client.URL(web.HKEX, fmt.Sprintf("/eng/csm/DailyStat/data_tab_daily_%se.js", d))
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return tabs, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return tabs, fmt.Errorf("data not ready - %s", date)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return tabs, err
+	}
+
+	// e.g. tabData = [{...}]
+	content := string(body)
+	if i := strings.Index(content, "="); i >= 0 {
+		content = content[i+1:]
+	}
+	content = strings.TrimSuffix(strings.TrimSpace(content), ";")
+
+	if err := json.Unmarshal([]byte(content), &tabs); err != nil {
+		return tabs, err
+	}
+	return tabs, nil
+}
@@ -0,0 +1,63 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetConnectTurnover(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetConnectTurnover("2021-02-26")
+	if err != nil {
+		t.Fatalf("GetConnectTurnover() error = %v", err)
+	}
+	want := []ConnectTurnover{
+		{Date: "2021-02-26", Market: "SSE Northbound", TotalTurnover: 60123.45, BuyTurnover: 28456.78, SellTurnover: 31666.67, TotalTrades: 3456789, BuyTrades: 1623456, SellTrades: 1833333},
+		{Date: "2021-02-26", Market: "SSE Southbound", TotalTurnover: 30012.34, BuyTurnover: 17345.67, SellTurnover: 12666.67, TotalTrades: 456789, BuyTrades: 256789, SellTrades: 200000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetConnectTurnover() = %v, want %v", got, want)
+	}
+}
+
+func TestGetConnectTopTraded(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetConnectTopTraded("2021-02-26")
+	if err != nil {
+		t.Fatalf("GetConnectTopTraded() error = %v", err)
+	}
+	want := []ConnectTopTraded{
+		{Date: "2021-02-26", Market: "SSE Northbound", Rank: 1, Code: "600519", Name: "KWEICHOW MOUTAI", BuyTurnover: 1234567890, SellTurnover: 987654321, TotalTurnover: 2222222211},
+		{Date: "2021-02-26", Market: "SSE Northbound", Rank: 2, Code: "601318", Name: "PING AN", BuyTurnover: 654321000, SellTurnover: 543210000, TotalTurnover: 1197531000},
+		{Date: "2021-02-26", Market: "SSE Southbound", Rank: 1, Code: "00700", Name: "TENCENT", BuyTurnover: 3456789012, SellTurnover: 2345678901, TotalTurnover: 5802467913},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetConnectTopTraded() = %v, want %v", got, want)
+	}
+}
+
+func TestGetConnectTurnover_NotReady(t *testing.T) {
+	newTestServer(t)
+
+	if _, err := GetConnectTurnover("2021-03-01"); err == nil {
+		t.Errorf("GetConnectTurnover() error = nil, want data not ready")
+	}
+}
+
+func TestGetSouthboundHoldings(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetSouthboundHoldings("2021-02-26")
+	if err != nil {
+		t.Fatalf("GetSouthboundHoldings() error = %v", err)
+	}
+	want := []ConnectHolding{
+		{Date: "2021-02-26", Code: "00005", Name: "HSBC HOLDINGS PLC", Shareholding: 1672316590, Pct: 8.07},
+		{Date: "2021-02-26", Code: "00700", Name: "TENCENT HOLDINGS LIMITED", Shareholding: 721563200, Pct: 7.53},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSouthboundHoldings() = %v, want %v", got, want)
+	}
+}
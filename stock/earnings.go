@@ -0,0 +1,147 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/lib/pq"
+)
+
+// Earnings from aastock
+type Earnings struct {
+	Date       string
+	Sector     string
+	Industry   string
+	Code       string
+	Close      float64
+	Period     string  // reporting period, e.g. 2020/12 Final
+	EPS        float64 // latest EPS
+	EPSGrowth  float64 // EPS growth (%)
+	NextResult string  // next results date if announced, e.g. 2021/08/02
+}
+
+// GetIndustryEarnings gets the earnings of all the sectors + industry code
+func GetIndustryEarnings(date string) ([]Earnings, error) {
+	return GetIndustryEarningsContext(context.Background(), date)
+}
+
+// GetIndustryEarningsContext gets the earnings of all the sectors + industry code
+func GetIndustryEarningsContext(ctx context.Context, date string) ([]Earnings, error) {
+	var results []Earnings
+
+	exist, err := db.RecordExistsContext(ctx, "industry_earnings", date)
+	if err != nil {
+		return results, err
+	}
+	if exist {
+		return results, fmt.Errorf("records exists in db - %s", date)
+	}
+
+	links, err := getIndustryLinks(ctx, date, 6) // check dates
+	if err != nil {
+		return results, err
+	}
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		rec, err := getEarnings(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, rec...)
+	}
+	return results, nil
+}
+
+// InsertEarnings inserts to the industry_earnings table
+func InsertEarnings(data []Earnings) error {
+	return InsertEarningsContext(context.Background(), data)
+}
+
+// InsertEarningsContext inserts to the industry_earnings table
+func InsertEarningsContext(ctx context.Context, data []Earnings) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry_earnings", "date", "sector", "industry", "code", "close", "period", "eps", "epsgrowth", "nextresult"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.Code, model.Close, model.Period, model.EPS, model.EPSGrowth, model.NextResult)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getEarnings gets the earnings tab of a single industry
+func getEarnings(ctx context.Context, date, link string) ([]Earnings, error) {
+	var results []Earnings
+
+	sector, industry, rows, err := getIndustryTable(ctx, link)
+	if err != nil {
+		return results, err
+	}
+
+	// Code | Name | Close | Chg | Chg% | Period | EPS | EPS Growth | Next Result Date
+	for _, values := range rows {
+		if len(values) == 9 {
+			close, _ := util.ParseF(values[2])
+			eps, _ := util.ParseF(values[6])
+			epsGrowth, _ := util.ParseF(values[7])
+
+			nextResult := values[8]
+			if nextResult == "N/A" || nextResult == "-" {
+				nextResult = ""
+			}
+
+			rec := Earnings{
+				Date:       date,
+				Sector:     sector,
+				Industry:   industry,
+				Code:       values[0],
+				Close:      close,
+				Period:     values[5],
+				EPS:        eps,
+				EPSGrowth:  epsGrowth,
+				NextResult: nextResult,
+			}
+			results = append(results, rec)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,27 @@
+package stock
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestGetEarnings(t *testing.T) {
+	newTestServer(t)
+
+	link := client.URL(web.AAStocks, "/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=6&s=&o=&p=")
+	got, err := getEarnings(context.Background(), "2021-02-26", link)
+	if err != nil {
+		t.Fatalf("getEarnings() error = %v", err)
+	}
+	want := []Earnings{
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00301", Close: 1.25, Period: "2020/06 Interim", EPS: 0.068, EPSGrowth: 15.25, NextResult: "2021/03/25"},
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00189", Close: 6.89, Period: "2019/12 Final", EPS: 0.311, EPSGrowth: -35.74},
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "01662", Close: 0.42, Period: "2020/09 Final", EPS: -0.012},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getEarnings() = %v, want %v", got, want)
+	}
+}
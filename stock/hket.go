@@ -1,41 +1,144 @@
 package stock
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/db"
 	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
 )
 
-func HketTest() error {
+// EarningsForecast is the broker consensus from hket
+type EarningsForecast struct {
+	Date       string
+	Code       string
+	FiscalYear string  // e.g. 2021
+	EPS        float64 // EPS forecast
+	DPS        float64 // DPS forecast
+	Brokers    int     // no of brokers covered
+	Rating     string  // consensus rating, e.g. Buy
+}
+
+// ForwardPE derives the forward PE from the close price and the EPS forecast
+func (e EarningsForecast) ForwardPE(close float64) float64 {
+	if e.EPS == 0 {
+		return 0
+	}
+	return close / e.EPS
+}
+
+// GetEarningsForecast gets the earnings forecasts of a single stock from hket
+func GetEarningsForecast(code int) ([]EarningsForecast, error) {
+	return GetEarningsForecastContext(context.Background(), code)
+}
+
+// GetEarningsForecastContext gets the earnings forecasts of a single stock from hket
+func GetEarningsForecastContext(ctx context.Context, code int) ([]EarningsForecast, error) {
 	// https://invest.hket.com/markets
 	// https://invest.hket.com/market-store/board_meeting/earnings_forecasts_bycode.html
 
-	var foo Foo
-	err := getJson("http://example.com", foo)
+	var results []EarningsForecast
+
+	codeF := fmt.Sprintf("%05d", code)
+	date := time.Now().Format("2006-01-02")
+	link := client.URL(web.HKET, fmt.Sprintf("/market-store/board_meeting/earnings_forecasts_bycode.html?code=%s", codeF))
+	res, err := client.GetContext(ctx, link)
 	if err != nil {
-		return err
+		return results, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
-	fmt.Println("go")
 
-	fmt.Println(util.PrettyPrint(foo))
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return results, err
+	}
 
-	return nil
+	// Fiscal Year | EPS | DPS | No. of Brokers | Consensus Rating
+	doc.Find("table tbody tr").Each(func(i int, tr *goquery.Selection) {
+		var values []string
+		tr.Find("td").Each(func(j int, td *goquery.Selection) {
+			values = append(values, strings.TrimSpace(td.Text()))
+		})
+
+		if len(values) == 5 {
+			eps, _ := util.ParseF(values[1])
+			dps, _ := util.ParseF(values[2])
+			brokers, _ := strconv.Atoi(values[3])
+
+			rec := EarningsForecast{
+				Date:       date,
+				Code:       codeF,
+				FiscalYear: values[0],
+				EPS:        eps,
+				DPS:        dps,
+				Brokers:    brokers,
+				Rating:     values[4],
+			}
+			results = append(results, rec)
+		}
+	})
+	if len(results) == 0 {
+		return results, fmt.Errorf("no earnings forecast - %s", codeF)
+	}
+	return results, nil
 }
 
-type Foo struct {
-	Bar string
+// InsertEarningsForecast inserts to the earnings_forecast table
+func InsertEarningsForecast(data []EarningsForecast) error {
+	return InsertEarningsForecastContext(context.Background(), data)
 }
 
-func getJson(url string, target interface{}) error {
-	var myClient = &http.Client{Timeout: 10 * time.Second}
-	r, err := myClient.Get(url)
+// InsertEarningsForecastContext inserts to the earnings_forecast table
+func InsertEarningsForecastContext(ctx context.Context, data []EarningsForecast) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("earnings_forecast", "date", "code", "fiscalyear", "eps", "dps", "brokers", "rating"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Code, model.FiscalYear, model.EPS, model.DPS, model.Brokers, model.Rating)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
-	defer r.Body.Close()
 
-	return json.NewDecoder(r.Body).Decode(target)
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
 }
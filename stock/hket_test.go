@@ -0,0 +1,44 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetEarningsForecast(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetEarningsForecast(700)
+	if err != nil {
+		t.Fatalf("GetEarningsForecast() error = %v", err)
+	}
+	date := time.Now().Format("2006-01-02")
+	want := []EarningsForecast{
+		{Date: date, Code: "00700", FiscalYear: "2021", EPS: 13.452, DPS: 1.85, Brokers: 42, Rating: "Buy"},
+		{Date: date, Code: "00700", FiscalYear: "2022", EPS: 16.208, DPS: 2.18, Brokers: 40, Rating: "Buy"},
+		{Date: date, Code: "00700", FiscalYear: "2023", EPS: 19.73, DPS: 0, Brokers: 25, Rating: "Outperform"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEarningsForecast() = %v, want %v", got, want)
+	}
+}
+
+func TestEarningsForecast_ForwardPE(t *testing.T) {
+	tests := []struct {
+		name  string
+		eps   float64
+		close float64
+		want  float64
+	}{
+		{"eps", 13.5, 675, 50},
+		{"no eps", 0, 675, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (EarningsForecast{EPS: tt.eps}).ForwardPE(tt.close); got != tt.want {
+				t.Errorf("ForwardPE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
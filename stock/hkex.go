@@ -1,87 +1,106 @@
 package stock
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/web"
 )
 
-type Company struct {
-	Code string
-	Name string
+// GetSecurities looks up all the securities from the HKEX list of securities,
+// falling back to the hkexnews stock list classified by the code and name
+func GetSecurities() ([]Security, error) {
+	return GetSecuritiesContext(context.Background())
 }
 
-// GetCompanyName looks up company name from HKEX
-func getcompanyname(c int) (Company, error) {
-	var result Company
+// GetSecuritiesContext looks up all the securities as GetSecurities, with the context for the requests
+func GetSecuritiesContext(ctx context.Context) ([]Security, error) {
+	result, err := getListOfSecurities(ctx)
+	if err == nil {
+		return result, nil
+	}
+	fmt.Printf("Unable to get the list of securities, fall back to the stock list - %v\n", err)
+	return getSecurities(ctx)
+}
 
-	// Handle input, e.g. code = 00005, date 2021-02-01
-	targetCode := fmt.Sprintf("%05d", c) // zfill to 5 digit
-	currentTime := time.Now()
-	d := currentTime.Format("2006-01-02")
-	d = strings.ReplaceAll(d, "-", "") // date in string format
+// getSecurities looks up all the securities on the hkexnews stock list, classified by the code and name
+func getSecurities(ctx context.Context) ([]Security, error) {
+	var result []Security
 
-	url := fmt.Sprintf("https://www.hkexnews.hk/sdw/search/stocklist_c.aspx?sortby=stockcode&shareholdingdate=%s", d)
-	res, err := http.Get(url)
+	companies, err := getStockList(ctx, english)
 	if err != nil {
 		return result, err
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return result, err
+	for _, company := range companies {
+		code, err := strconv.Atoi(company.Code)
+		if err != nil { // ignore
+			fmt.Println(err.Error())
+			continue
+		}
+		result = append(result, Classify(code, company.Name))
 	}
+	return result, nil
+}
 
-	// Load the HTML document
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+// GetCompanyList looks up all the companies' code on HKEX.
+// By default it keeps the 4 digit main board codes, i.e. equities, ETFs, REITs and debt securities trading in HKD or USD,
+// use the options, e.g. EquitiesOnly(), WithTypes(Equity, ETF, REIT) or IncludeGEM(), to choose the universe.
+func GetCompanyList(opts ...ListOption) ([]int, error) {
+	return GetCompanyListContext(context.Background(), opts...)
+}
+
+// GetCompanyListContext looks up all the companies' code on HKEX as GetCompanyList, with the context for the requests
+func GetCompanyListContext(ctx context.Context, opts ...ListOption) ([]int, error) {
+	var result []int
+
+	options := defaultListOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	securities, err := GetSecuritiesContext(ctx)
 	if err != nil {
 		return result, err
 	}
-
-	// Find the review items
-	doc.Find("table.table > tbody > tr").Each(func(i int, s *goquery.Selection) {
-		// For each item found, get the band and title
-
-		content := s.Find("td").Text()
-		regex := *regexp.MustCompile(`\s*(\d{5})\s*(.*)`)
-		matched := regex.FindAllStringSubmatch(content, -1)
-		for i := range matched {
-			codeStr := matched[i][1]
-			companyStr := matched[i][2]
-
-			if codeStr == targetCode {
-				result = Company{
-					Code: targetCode,
-					Name: companyStr,
-				}
-				break // find then break
-			}
+	for _, s := range securities {
+		if options.match(s) {
+			result = append(result, s.Code)
 		}
-	})
+	}
+	if len(result) == 0 {
+		return result, errors.New("something wrong with the hkex company list")
+	}
 	return result, nil
 }
 
-// GetCompanyList looks up all the companies' code on HKEX
-func GetCompanyList() ([]int, error) {
-	var result []int
+// Languages of the hkexnews CCASS stock list
+const (
+	english = ""
+	chinese = "_c"
+)
+
+// getStockList gets the code and name of all the securities from the hkexnews CCASS stock list
+func getStockList(ctx context.Context, lang string) ([]Company, error) {
+	var result []Company
 
 	currentTime := time.Now()
 	d := currentTime.Format("2006-01-02")
 	d = strings.ReplaceAll(d, "-", "") // date in string format
 
-	url := fmt.Sprintf("https://www.hkexnews.hk/sdw/search/stocklist_c.aspx?sortby=stockcode&shareholdingdate=%s", d)
-	res, err := http.Get(url)
+	link := client.URL(web.HKEXNews, fmt.Sprintf("/sdw/search/stocklist%s.aspx?sortby=stockcode&shareholdingdate=%s", lang, d))
+	res, err := client.GetContext(ctx, link)
 	if err != nil {
 		return result, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		return result, err
+		return result, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
 
 	// Load the HTML document
@@ -91,23 +110,17 @@ func GetCompanyList() ([]int, error) {
 	}
 
 	// Find the review items
+	regex := *regexp.MustCompile(`\s*(\d{5})\s*(.*)`)
 	doc.Find("table.table > tbody > tr").Each(func(i int, s *goquery.Selection) {
 		// For each item found, get the band and title
 
 		content := s.Find("td").Text()
-		regex := *regexp.MustCompile(`\s*(\d{5})\s*(.*)`)
 		matched := regex.FindAllStringSubmatch(content, -1)
 		for i := range matched {
-			codeF := matched[i][1]
-			code, err := strconv.Atoi(codeF)
-			if err != nil { // ignore
-				fmt.Println(err.Error())
-			}
-			if code < 10000 {
-				if code <= 8000 || code >= 9000 {
-					result = append(result, code)
-				}
-			}
+			result = append(result, Company{
+				Code: matched[i][1],
+				Name: strings.TrimSpace(matched[i][2]),
+			})
 		}
 	})
 	if len(result) == 0 {
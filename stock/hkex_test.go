@@ -3,9 +3,11 @@ package stock
 import (
 	"reflect"
 	"testing"
+
+	"github.com/billylkc/stocklib/web"
 )
 
-func Test_getcompanyname(t *testing.T) {
+func TestLookupCompany(t *testing.T) {
 	type args struct {
 		c int
 	}
@@ -15,33 +17,71 @@ func Test_getcompanyname(t *testing.T) {
 		want    Company
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{"equity", args{5}, Company{Code: "00005", Name: "HSBC HOLDINGS", NameZH: "滙豐控股", ListingDate: "1991-07-29", Currency: "HKD"}, false},
+		{"equity cached", args{5}, Company{Code: "00005", Name: "HSBC HOLDINGS", NameZH: "滙豐控股", ListingDate: "1991-07-29", Currency: "HKD"}, false},
+		{"rmb counter without profile", args{80737}, Company{Code: "80737", Name: "SHENZHEN EXPRESSWAY-R", NameZH: "深圳高速－Ｒ", Currency: "CNY"}, false},
+		{"not found", args{99999}, Company{}, true},
 	}
+	newTestServer(t)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getcompanyname(tt.args.c)
+			got, err := LookupCompany(tt.args.c)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("getcompanyname() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("LookupCompany() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("getcompanyname() = %v, want %v", got, tt.want)
+				t.Errorf("LookupCompany() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestFillListingDates(t *testing.T) {
+	newTestServer(t)
+
+	data := []Company{{Code: "00005"}, {Code: "00001"}, {Code: "00823", ListingDate: "2005-11-25"}}
+	got, err := FillListingDates(data)
+	if err != nil {
+		t.Fatalf("FillListingDates() error = %v", err)
+	}
+	want := []Company{{Code: "00005", ListingDate: "1991-07-29"}, {Code: "00001"}, {Code: "00823", ListingDate: "2005-11-25"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FillListingDates() = %v, want %v", got, want)
+	}
+}
+
+func TestSetClientResetsDirectory(t *testing.T) {
+	newTestServer(t)
+	if _, err := LoadCompanies(); err != nil {
+		t.Fatal(err)
+	}
+
+	SetClient(web.New())
+	directory.Lock()
+	loaded := directory.list != nil
+	directory.Unlock()
+	if loaded {
+		t.Errorf("SetClient() kept the company directory of the previous client")
+	}
+}
+
 func TestGetCompanyList(t *testing.T) {
 	tests := []struct {
 		name    string
+		opts    []ListOption
 		want    []int
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{"default", nil, []int{1, 5, 823, 2800}, false},
+		{"equities only", []ListOption{EquitiesOnly()}, []int{1, 5}, false},
+		{"include gem", []ListOption{IncludeGEM()}, []int{1, 5, 823, 2800, 8083}, false},
+		{"all", []ListOption{AllSecurities()}, []int{1, 5, 823, 2800, 8083, 12345, 80737}, false},
 	}
+	newTestServer(t)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetCompanyList()
+			got, err := GetCompanyList(tt.opts...)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetCompanyList() error = %v, wantErr %v", err, tt.wantErr)
 				return
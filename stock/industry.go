@@ -1,17 +1,18 @@
 package stock
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"net/http"
 	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/calendar"
 	"github.com/billylkc/stocklib/db"
 	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
 	"github.com/lib/pq"
 )
 
@@ -34,20 +35,34 @@ type Industry struct {
 
 // Gets all the sectors + industry code
 func GetIndustryOverview(date string) ([]Industry, error) {
+	return GetIndustryOverviewContext(context.Background(), date)
+}
+
+// GetIndustryOverviewContext gets all the sectors + industry code, stopping between industries when the context is done
+func GetIndustryOverviewContext(ctx context.Context, date string) ([]Industry, error) {
 	var results []Industry
 
-	exist := db.RecordExists("industry", date)
+	exist, err := db.RecordExistsContext(ctx, "industry", date)
+	if err != nil {
+		return results, err
+	}
 	if exist {
 		return results, fmt.Errorf("records exists in db - %s", date)
 	}
 
-	links, err := getIndustryLinks(date, 1) // check dates
+	links, err := getIndustryLinks(ctx, date, 1) // check dates
 	if err != nil {
 		return results, err
 	}
 
 	for _, link := range links {
-		industry, _ := getIndustryOverview(date, link)
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		industry, err := getIndustryOverview(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
 		results = append(results, industry...)
 	}
 	return results, nil
@@ -55,6 +70,11 @@ func GetIndustryOverview(date string) ([]Industry, error) {
 
 // InsertIndustry inserts to the industry table
 func InsertIndustry(data []Industry) error {
+	return InsertIndustryContext(context.Background(), data)
+}
+
+// InsertIndustryContext inserts to the industry table
+func InsertIndustryContext(ctx context.Context, data []Industry) error {
 
 	if len(data) == 0 {
 		return errors.New("no records to be inserted")
@@ -66,26 +86,26 @@ func InsertIndustry(data []Industry) error {
 		return err
 	}
 
-	txn, err := db.Begin()
+	txn, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer txn.Commit()
 
-	stmt, err := txn.Prepare(pq.CopyIn("industry", "date", "sector", "industry", "code", "close", "change", "changepct", "volume", "turnover", "pe", "pb", "yieldpct", "marketcap"))
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry", "date", "sector", "industry", "code", "close", "change", "changepct", "volume", "turnover", "pe", "pb", "yieldpct", "marketcap"))
 	if err != nil {
 		return (err)
 	}
 
 	for _, model := range data {
-		_, err := stmt.Exec(model.Date, model.Sector, model.Industry, model.CodeF, model.Close, model.Change, model.ChangePct, model.Volume, model.Turnover, model.PE, model.PB, model.YieldPct, model.MarketCap)
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.CodeF, model.Close, model.Change, model.ChangePct, model.Volume, model.Turnover, model.PE, model.PB, model.YieldPct, model.MarketCap)
 		if err != nil {
 			txn.Rollback()
 			return err
 		}
 	}
 
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -99,22 +119,22 @@ func InsertIndustry(data []Industry) error {
 }
 
 // getIndustryOverview gets a single industry overview from aastock
-func getIndustryOverview(date, link string) ([]Industry, error) {
+func getIndustryOverview(ctx context.Context, date, link string) ([]Industry, error) {
 
 	var result []Industry
 
-	res, err := http.Get(link)
+	res, err := client.GetContext(ctx, link)
 	if err != nil {
-		log.Fatal(err)
+		return result, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		log.Fatalf("status code error: %d %s", res.StatusCode, res.Status)
+		return result, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		return result, err
 	}
 
 	// Title
@@ -191,8 +211,56 @@ func getIndustryOverview(date, link string) ([]Industry, error) {
 	return result, nil
 }
 
+// getIndustryTable gets the sector, industry and the rows of the stock table from an industry tab page
+func getIndustryTable(ctx context.Context, link string) (string, string, [][]string, error) {
+	var (
+		sector   string
+		industry string
+		rows     [][]string
+	)
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return sector, industry, rows, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return sector, industry, rows, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return sector, industry, rows, err
+	}
+
+	doc.Find("h1").Each(func(i int, s *goquery.Selection) {
+		text := s.Text() // e.g. Industry Details - Materials - Chemical Products
+		texts := strings.Split(text, "-")
+		if len(texts) == 3 {
+			sector = strings.TrimSpace(texts[1])   // e.g. Materials
+			industry = strings.TrimSpace(texts[2]) // e.g. Chemical Products
+			fmt.Printf("Getting [%s] - [%s]\n", sector, industry)
+		}
+	})
+
+	// For each code inside a sector, gets the details
+	doc.Find("table#tbTS.tblM.s2").Each(func(i int, s *goquery.Selection) {
+		s.Find("tr").Each(func(j int, tr *goquery.Selection) {
+			var values []string
+			tr.Find("td").Each(func(k int, td *goquery.Selection) {
+				values = append(values, strings.TrimSpace(td.Text()))
+			})
+			if len(values) > 0 && strings.HasSuffix(values[0], ".HK") {
+				values[0] = strings.ReplaceAll(values[0], ".HK", "") // 00301.HK -> 00301
+				rows = append(rows, values)
+			}
+		})
+	})
+	return sector, industry, rows, nil
+}
+
 // getIndustryLinks gets all the individual sector/industires links
-func getIndustryLinks(date string, tab int) ([]string, error) {
+func getIndustryLinks(ctx context.Context, date string, tab int) ([]string, error) {
 	// tab reference
 	// 1 - Overview
 	// 2 - Range
@@ -203,13 +271,23 @@ func getIndustryLinks(date string, tab int) ([]string, error) {
 
 	var links []string
 
+	if err := loadClosures(ctx); err != nil {
+		return links, err
+	}
+	if !calendar.IsTradingDay(date) {
+		return links, fmt.Errorf("not a trading day - %s", date)
+	}
+
 	// Check if data is ready
-	dataReady := util.CheckWebsiteDate(date)
+	dataReady, err := util.CheckWebsiteDateContext(ctx, client, date)
+	if err != nil {
+		return links, err
+	}
 	if !dataReady {
 		return links, fmt.Errorf("data not ready - %s", date)
 	}
 
-	res, err := http.Get("http://www.aastocks.com/en/stocks/market/industry/sector-industry-details.aspx")
+	res, err := client.GetContext(ctx, client.URL(web.AAStocks, "/en/stocks/market/industry/sector-industry-details.aspx"))
 	if err != nil {
 		return links, err
 	}
@@ -224,10 +302,22 @@ func getIndustryLinks(date string, tab int) ([]string, error) {
 	for _, match := range matches {
 		if len(match) >= 2 {
 			industryCode := match[1]
-			link := fmt.Sprintf("http://www.aastocks.com/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=%s&t=%d&s=&o=&p=", industryCode, tab)
+			link := client.URL(web.AAStocks, fmt.Sprintf("/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=%s&t=%d&s=&o=&p=", industryCode, tab))
 
 			links = append(links, link)
 		}
 	}
 	return links, nil
 }
+
+// loadClosures loads the recorded market closures for the trading day checks.
+// Without the database only the weekends and holidays are checked, unless the context is done.
+func loadClosures(ctx context.Context) error {
+	if err := calendar.LoadClosuresOnceContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fmt.Printf("Unable to load the market closures - %v\n", err)
+	}
+	return nil
+}
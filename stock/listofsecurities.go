@@ -0,0 +1,104 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/xuri/excelize/v2"
+)
+
+// categoryTypes maps the categories of the HKEX list of securities to the security types
+var categoryTypes = map[string]SecurityType{
+	"Equity":                        Equity,
+	"Exchange Traded Products":      ETF,
+	"Real Estate Investment Trusts": REIT,
+	"Derivative Warrants":           Warrant,
+	"Inline Warrants":               Warrant,
+	"Callable Bull/Bear Contracts":  CBBC,
+	"Debt Securities":               Debt,
+}
+
+// getListOfSecurities gets all the securities from the HKEX list of securities spreadsheet
+func getListOfSecurities(ctx context.Context) ([]Security, error) {
+	link := client.URL(web.HKEX, "/eng/services/trading/securities/securitieslists/ListOfSecurities.xlsx")
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return []Security{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return []Security{}, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+	return parseListOfSecurities(res.Body)
+}
+
+// parseListOfSecurities parses the spreadsheet, with title rows above the header row
+func parseListOfSecurities(r io.Reader) ([]Security, error) {
+	var result []Security
+
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return result, err
+	}
+
+	// Stock Code | Name of Securities | Category | Sub-Category | Board Lot | Par Value | ISIN | ...
+	var columns map[string]int
+	for _, row := range rows {
+		if columns == nil {
+			if len(row) > 0 && strings.TrimSpace(row[0]) == "Stock Code" {
+				columns = make(map[string]int)
+				for j, cell := range row {
+					columns[strings.TrimSpace(cell)] = j
+				}
+			}
+			continue
+		}
+
+		cell := func(name string) string {
+			j, ok := columns[name]
+			if !ok || j >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[j])
+		}
+
+		code, err := util.ParseN(cell("Stock Code"))
+		if err != nil {
+			continue
+		}
+		s := Classify(code, cell("Name of Securities"))
+		s.Category = cell("Category")
+		s.SubCategory = cell("Sub-Category")
+		s.ParValue = cell("Par Value")
+		s.ISIN = cell("ISIN")
+		s.LotSize, _ = util.ParseN(cell("Board Lot"))
+
+		if t, ok := categoryTypes[s.Category]; ok {
+			s.Type = t
+		} else {
+			s.Type = Other
+		}
+		if strings.Contains(s.SubCategory, "GEM") {
+			s.Board = GEM
+		} else {
+			s.Board = MainBoard
+		}
+		result = append(result, s)
+	}
+	if len(result) == 0 {
+		return result, errors.New("something wrong with the hkex list of securities")
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package stock
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func Test_parseListOfSecurities(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	rows := [][]interface{}{
+		{"List of Securities"},
+		{"Updated as at 26/02/2021"},
+		{"Stock Code", "Name of Securities", "Category", "Sub-Category", "Board Lot", "Par Value", "ISIN"},
+		{"00005", "HSBC HOLDINGS", "Equity", "Equity Securities (Main Board)", "400", "USD 0.50", "GB0005405286"},
+		{"00823", "LINK REIT", "Real Estate Investment Trusts", "", "100", "", "HK0823032773"},
+		{"08083", "CHINA YOUZAN", "Equity", "Equity Securities (GEM)", "4,000", "HKD 0.0001", "BMG2153A1000"},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseListOfSecurities(&buf)
+	if err != nil {
+		t.Fatalf("parseListOfSecurities() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("parseListOfSecurities() got %d records, want 3", len(got))
+	}
+
+	tests := []struct {
+		name     string
+		idx      int
+		wantType SecurityType
+		board    Board
+		lotSize  int
+		isin     string
+	}{
+		{"equity", 0, Equity, MainBoard, 400, "GB0005405286"},
+		{"reit", 1, REIT, MainBoard, 100, "HK0823032773"},
+		{"gem", 2, Equity, GEM, 4000, "BMG2153A1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := got[tt.idx]
+			if s.Type != tt.wantType || s.Board != tt.board || s.LotSize != tt.lotSize || s.ISIN != tt.isin {
+				t.Errorf("parseListOfSecurities() = %v", s)
+			}
+		})
+	}
+}
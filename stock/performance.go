@@ -1,10 +1,9 @@
 package stock
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -31,20 +30,34 @@ type Performance struct {
 
 // Gets all the sectors + industry code
 func GetIndustryPerformance(date string) ([]Performance, error) {
+	return GetIndustryPerformanceContext(context.Background(), date)
+}
+
+// GetIndustryPerformanceContext gets the performance of all the industries, stopping when the context is done
+func GetIndustryPerformanceContext(ctx context.Context, date string) ([]Performance, error) {
 	var results []Performance
 
-	exist := db.RecordExists("industry_performance", date)
+	exist, err := db.RecordExistsContext(ctx, "industry_performance", date)
+	if err != nil {
+		return results, err
+	}
 	if exist {
 		return results, fmt.Errorf("records exists in db - %s", date)
 	}
 
-	links, err := getIndustryLinks(date, 3) // check dates
+	links, err := getIndustryLinks(ctx, date, 3) // check dates
 	if err != nil {
 		return results, err
 	}
 
 	for _, link := range links {
-		rec, _ := getPerformance(date, link)
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		rec, err := getPerformance(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
 		results = append(results, rec...)
 	}
 	return results, nil
@@ -52,6 +65,11 @@ func GetIndustryPerformance(date string) ([]Performance, error) {
 
 // InsertPerformance inserts to the industry_performance table
 func InsertPerformance(data []Performance) error {
+	return InsertPerformanceContext(context.Background(), data)
+}
+
+// InsertPerformanceContext inserts to the industry_performance table
+func InsertPerformanceContext(ctx context.Context, data []Performance) error {
 
 	if len(data) == 0 {
 		return errors.New("no records to be inserted")
@@ -63,26 +81,26 @@ func InsertPerformance(data []Performance) error {
 		return err
 	}
 
-	txn, err := db.Begin()
+	txn, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer txn.Commit()
 
-	stmt, err := txn.Prepare(pq.CopyIn("industry_performance", "date", "sector", "industry", "code", "close", "threey", "oney", "sixm", "threem", "onem", "onew", "ytd"))
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry_performance", "date", "sector", "industry", "code", "close", "threey", "oney", "sixm", "threem", "onem", "onew", "ytd"))
 	if err != nil {
 		return (err)
 	}
 
 	for _, model := range data {
-		_, err := stmt.Exec(model.Date, model.Sector, model.Industry, model.Code, model.Close, model.ThreeY, model.OneY, model.SixM, model.ThreeM, model.OneM, model.OneW, model.Ytd)
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.Code, model.Close, model.ThreeY, model.OneY, model.SixM, model.ThreeM, model.OneM, model.OneW, model.Ytd)
 		if err != nil {
 			txn.Rollback()
 			return err
 		}
 	}
 
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -95,22 +113,22 @@ func InsertPerformance(data []Performance) error {
 	return nil
 }
 
-func getPerformance(date, link string) ([]Performance, error) {
+func getPerformance(ctx context.Context, date, link string) ([]Performance, error) {
 
 	var results []Performance
 
-	res, err := http.Get(link)
+	res, err := client.GetContext(ctx, link)
 	if err != nil {
-		log.Fatal(err)
+		return results, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		log.Fatalf("status code error: %d %s", res.StatusCode, res.Status)
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		return results, err
 	}
 
 	var (
@@ -0,0 +1,174 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/lib/pq"
+)
+
+// PriceRange from aastock
+type PriceRange struct {
+	Date       string
+	Sector     string
+	Industry   string
+	Code       string
+	Close      float64
+	OneMHigh   float64 // 1-month high
+	OneMLow    float64 // 1-month low
+	ThreeMHigh float64 // 3-months high
+	ThreeMLow  float64 // 3-months low
+	YearHigh   float64 // 52-weeks high
+	YearLow    float64 // 52-weeks low
+}
+
+// RangeDistance is the percentage distance of the close from the highs and lows
+type RangeDistance struct {
+	Code       string
+	OneMHigh   float64 // negative when below the high
+	OneMLow    float64 // positive when above the low
+	ThreeMHigh float64
+	ThreeMLow  float64
+	YearHigh   float64
+	YearLow    float64
+}
+
+// Distance derives the distance from the highs and lows in percentage
+func (p PriceRange) Distance() RangeDistance {
+	return RangeDistance{
+		Code:       p.Code,
+		OneMHigh:   util.PercentChange(p.Close, p.OneMHigh),
+		OneMLow:    util.PercentChange(p.Close, p.OneMLow),
+		ThreeMHigh: util.PercentChange(p.Close, p.ThreeMHigh),
+		ThreeMLow:  util.PercentChange(p.Close, p.ThreeMLow),
+		YearHigh:   util.PercentChange(p.Close, p.YearHigh),
+		YearLow:    util.PercentChange(p.Close, p.YearLow),
+	}
+}
+
+// GetIndustryRange gets the price range of all the sectors + industry code
+func GetIndustryRange(date string) ([]PriceRange, error) {
+	return GetIndustryRangeContext(context.Background(), date)
+}
+
+// GetIndustryRangeContext gets the price range of all the sectors + industry code
+func GetIndustryRangeContext(ctx context.Context, date string) ([]PriceRange, error) {
+	var results []PriceRange
+
+	exist, err := db.RecordExistsContext(ctx, "industry_range", date)
+	if err != nil {
+		return results, err
+	}
+	if exist {
+		return results, fmt.Errorf("records exists in db - %s", date)
+	}
+
+	links, err := getIndustryLinks(ctx, date, 2) // check dates
+	if err != nil {
+		return results, err
+	}
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		rec, err := getPriceRange(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, rec...)
+	}
+	return results, nil
+}
+
+// InsertPriceRange inserts to the industry_range table
+func InsertPriceRange(data []PriceRange) error {
+	return InsertPriceRangeContext(context.Background(), data)
+}
+
+// InsertPriceRangeContext inserts to the industry_range table
+func InsertPriceRangeContext(ctx context.Context, data []PriceRange) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry_range", "date", "sector", "industry", "code", "close", "onemhigh", "onemlow", "threemhigh", "threemlow", "yearhigh", "yearlow"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.Code, model.Close, model.OneMHigh, model.OneMLow, model.ThreeMHigh, model.ThreeMLow, model.YearHigh, model.YearLow)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getPriceRange gets the range tab of a single industry
+func getPriceRange(ctx context.Context, date, link string) ([]PriceRange, error) {
+	var results []PriceRange
+
+	sector, industry, rows, err := getIndustryTable(ctx, link)
+	if err != nil {
+		return results, err
+	}
+
+	// Code | Name | Close | Chg | Chg% | 1M High | 1M Low | 3M High | 3M Low | 52W High | 52W Low
+	for _, values := range rows {
+		if len(values) == 11 {
+			close, _ := util.ParseF(values[2])
+			oneMHigh, _ := util.ParseF(values[5])
+			oneMLow, _ := util.ParseF(values[6])
+			threeMHigh, _ := util.ParseF(values[7])
+			threeMLow, _ := util.ParseF(values[8])
+			yearHigh, _ := util.ParseF(values[9])
+			yearLow, _ := util.ParseF(values[10])
+
+			rec := PriceRange{
+				Date:       date,
+				Sector:     sector,
+				Industry:   industry,
+				Code:       values[0],
+				Close:      close,
+				OneMHigh:   oneMHigh,
+				OneMLow:    oneMLow,
+				ThreeMHigh: threeMHigh,
+				ThreeMLow:  threeMLow,
+				YearHigh:   yearHigh,
+				YearLow:    yearLow,
+			}
+			results = append(results, rec)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,34 @@
+package stock
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestGetPriceRange(t *testing.T) {
+	newTestServer(t)
+
+	link := client.URL(web.AAStocks, "/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=2&s=&o=&p=")
+	got, err := getPriceRange(context.Background(), "2021-02-26", link)
+	if err != nil {
+		t.Fatalf("getPriceRange() error = %v", err)
+	}
+	want := []PriceRange{
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00301", Close: 1.25, OneMHigh: 1.38, OneMLow: 1.15, ThreeMHigh: 1.42, ThreeMLow: 1.02, YearHigh: 1.65, YearLow: 0.81},
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00189", Close: 6.89, OneMHigh: 7.95, OneMLow: 5.6, ThreeMHigh: 7.95, ThreeMLow: 4.12, YearHigh: 7.95, YearLow: 2.57},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getPriceRange() = %v, want %v", got, want)
+	}
+}
+
+func TestPriceRange_Distance(t *testing.T) {
+	p := PriceRange{Code: "00005", Close: 10, OneMHigh: 20, OneMLow: 8, ThreeMHigh: 40, ThreeMLow: 5, YearHigh: 16, YearLow: 4}
+	want := RangeDistance{Code: "00005", OneMHigh: -50, OneMLow: 25, ThreeMHigh: -75, ThreeMLow: 100, YearHigh: -37.5, YearLow: 150}
+	if got := p.Distance(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Distance() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/lib/pq"
+)
+
+// FinancialRatio from aastock
+type FinancialRatio struct {
+	Date            string
+	Sector          string
+	Industry        string
+	Code            string
+	Close           float64
+	ROE             float64 // Return on Equity (%)
+	ROA             float64 // Return on Assets (%)
+	GrossMargin     float64 // (%)
+	OperatingMargin float64 // (%)
+	NetMargin       float64 // (%)
+	CurrentRatio    float64
+	QuickRatio      float64
+	DebtToEquity    float64 // Total Debt / Equity (%)
+	DebtToAsset     float64 // Total Debt / Total Assets (%)
+}
+
+// GetIndustryFinancialRatio gets the financial ratios of all the sectors + industry code
+func GetIndustryFinancialRatio(date string) ([]FinancialRatio, error) {
+	return GetIndustryFinancialRatioContext(context.Background(), date)
+}
+
+// GetIndustryFinancialRatioContext gets the financial ratios of all the sectors + industry code
+func GetIndustryFinancialRatioContext(ctx context.Context, date string) ([]FinancialRatio, error) {
+	var results []FinancialRatio
+
+	exist, err := db.RecordExistsContext(ctx, "industry_ratio", date)
+	if err != nil {
+		return results, err
+	}
+	if exist {
+		return results, fmt.Errorf("records exists in db - %s", date)
+	}
+
+	links, err := getIndustryLinks(ctx, date, 4) // check dates
+	if err != nil {
+		return results, err
+	}
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		rec, err := getFinancialRatio(ctx, date, link)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, rec...)
+	}
+	return results, nil
+}
+
+// InsertFinancialRatio inserts to the industry_ratio table
+func InsertFinancialRatio(data []FinancialRatio) error {
+	return InsertFinancialRatioContext(context.Background(), data)
+}
+
+// InsertFinancialRatioContext inserts to the industry_ratio table
+func InsertFinancialRatioContext(ctx context.Context, data []FinancialRatio) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("industry_ratio", "date", "sector", "industry", "code", "close", "roe", "roa", "grossmargin", "operatingmargin", "netmargin", "currentratio", "quickratio", "debttoequity", "debttoasset"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.Industry, model.Code, model.Close, model.ROE, model.ROA, model.GrossMargin, model.OperatingMargin, model.NetMargin, model.CurrentRatio, model.QuickRatio, model.DebtToEquity, model.DebtToAsset)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getFinancialRatio gets the financial ratio tab of a single industry
+func getFinancialRatio(ctx context.Context, date, link string) ([]FinancialRatio, error) {
+	var results []FinancialRatio
+
+	sector, industry, rows, err := getIndustryTable(ctx, link)
+	if err != nil {
+		return results, err
+	}
+
+	// Code | Name | Close | Chg | Chg% | ROE | ROA | Gross Margin | Operating Margin | Net Margin | Current Ratio | Quick Ratio | Debt/Equity | Debt/Assets
+	for _, values := range rows {
+		if len(values) == 14 {
+			close, _ := util.ParseF(values[2])
+			roe, _ := util.ParseF(values[5])
+			roa, _ := util.ParseF(values[6])
+			grossMargin, _ := util.ParseF(values[7])
+			operatingMargin, _ := util.ParseF(values[8])
+			netMargin, _ := util.ParseF(values[9])
+			currentRatio, _ := util.ParseF(values[10])
+			quickRatio, _ := util.ParseF(values[11])
+			debtToEquity, _ := util.ParseF(values[12])
+			debtToAsset, _ := util.ParseF(values[13])
+
+			rec := FinancialRatio{
+				Date:            date,
+				Sector:          sector,
+				Industry:        industry,
+				Code:            values[0],
+				Close:           close,
+				ROE:             roe,
+				ROA:             roa,
+				GrossMargin:     grossMargin,
+				OperatingMargin: operatingMargin,
+				NetMargin:       netMargin,
+				CurrentRatio:    currentRatio,
+				QuickRatio:      quickRatio,
+				DebtToEquity:    debtToEquity,
+				DebtToAsset:     debtToAsset,
+			}
+			results = append(results, rec)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,26 @@
+package stock
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestGetFinancialRatio(t *testing.T) {
+	newTestServer(t)
+
+	link := client.URL(web.AAStocks, "/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=4&s=&o=&p=")
+	got, err := getFinancialRatio(context.Background(), "2021-02-26", link)
+	if err != nil {
+		t.Fatalf("getFinancialRatio() error = %v", err)
+	}
+	want := []FinancialRatio{
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00301", Close: 1.25, ROE: 12.35, ROA: 6.48, GrossMargin: 18.2, OperatingMargin: 8.75, NetMargin: 6.91, CurrentRatio: 1.82, QuickRatio: 1.35, DebtToEquity: 24.6, DebtToAsset: 12.8},
+		{Date: "2021-02-26", Sector: "Materials", Industry: "Chemical Products", Code: "00189", Close: 6.89, ROE: 8.02, ROA: 4.91, GrossMargin: 21.45, NetMargin: 5.33, CurrentRatio: 1.21, QuickRatio: 0.98},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getFinancialRatio() = %v, want %v", got, want)
+	}
+}
@@ -1,16 +1,17 @@
 package stock
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
-	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/billylkc/stocklib/calendar"
 	"github.com/billylkc/stocklib/db"
 	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
 	"github.com/lib/pq"
 )
 
@@ -32,32 +33,50 @@ type Sector struct {
 }
 
 func GetSectorOveriew(date string) ([]Sector, error) {
+	return GetSectorOveriewContext(context.Background(), date)
+}
+
+// GetSectorOveriewContext gets the sector overview, stopping when the context is done
+func GetSectorOveriewContext(ctx context.Context, date string) ([]Sector, error) {
 	var results []Sector
-	link := "http://www.aastocks.com/en/stocks/market/industry/industry-performance.aspx"
+	link := client.URL(web.AAStocks, "/en/stocks/market/industry/industry-performance.aspx")
+
+	if err := loadClosures(ctx); err != nil {
+		return results, err
+	}
+	if !calendar.IsTradingDay(date) {
+		return results, fmt.Errorf("not a trading day - %s", date)
+	}
 
-	exist := db.RecordExists("sector", date)
+	exist, err := db.RecordExistsContext(ctx, "sector", date)
+	if err != nil {
+		return results, err
+	}
 	if exist {
 		return results, fmt.Errorf("records exists in db - %s", date)
 	}
 
 	// Check if data is ready
-	dataReady := util.CheckWebsiteDate(date)
+	dataReady, err := util.CheckWebsiteDateContext(ctx, client, date)
+	if err != nil {
+		return results, err
+	}
 	if !dataReady {
 		return results, fmt.Errorf("data not ready - %s", date)
 	}
 
-	res, err := http.Get(link)
+	res, err := client.GetContext(ctx, link)
 	if err != nil {
-		log.Fatal(err)
+		return results, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		log.Fatalf("status code error: %d %s", res.StatusCode, res.Status)
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		return results, err
 	}
 
 	var (
@@ -124,6 +143,11 @@ func GetSectorOveriew(date string) ([]Sector, error) {
 
 // InsertSector inserts to the sector table
 func InsertSector(data []Sector) error {
+	return InsertSectorContext(context.Background(), data)
+}
+
+// InsertSectorContext inserts to the sector table
+func InsertSectorContext(ctx context.Context, data []Sector) error {
 
 	if len(data) == 0 {
 		return errors.New("no records to be inserted")
@@ -135,26 +159,26 @@ func InsertSector(data []Sector) error {
 		return err
 	}
 
-	txn, err := db.Begin()
+	txn, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer txn.Commit()
 
-	stmt, err := txn.Prepare(pq.CopyIn("sector", "date", "sector", "changepct", "pchangepct", "turnover", "avgturnover", "avgpe", "zonea", "zoneb", "zonec", "zoned", "zonee", "zonen"))
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("sector", "date", "sector", "changepct", "pchangepct", "turnover", "avgturnover", "avgpe", "zonea", "zoneb", "zonec", "zoned", "zonee", "zonen"))
 	if err != nil {
 		return (err)
 	}
 
 	for _, model := range data {
-		_, err := stmt.Exec(model.Date, model.Sector, model.ChangePct, model.PchangePct, model.Turnover, model.AvgTurnover, model.AvgPE, model.ZoneA, model.ZoneB, model.ZoneC, model.ZoneD, model.ZoneE, model.ZoneN)
+		_, err := stmt.ExecContext(ctx, model.Date, model.Sector, model.ChangePct, model.PchangePct, model.Turnover, model.AvgTurnover, model.AvgPE, model.ZoneA, model.ZoneB, model.ZoneC, model.ZoneD, model.ZoneE, model.ZoneN)
 		if err != nil {
 			txn.Rollback()
 			return err
 		}
 	}
 
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,179 @@
+package stock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecurityType of a listed security
+type SecurityType string
+
+const (
+	Equity  SecurityType = "Equity"
+	ETF     SecurityType = "ETF" // including leveraged and inverse products
+	REIT    SecurityType = "REIT"
+	Warrant SecurityType = "Warrant" // derivative and inline warrants
+	CBBC    SecurityType = "CBBC"
+	Debt    SecurityType = "Debt"
+	Other   SecurityType = "Other"
+)
+
+// Board of the listing
+type Board string
+
+const (
+	MainBoard Board = "Main"
+	GEM       Board = "GEM"
+)
+
+// Security is a listed security on HKEX
+type Security struct {
+	Code     int
+	CodeF    string // code in 5 digit format, e.g. 00005
+	Name     string
+	Type     SecurityType
+	Board    Board
+	Currency string // trading currency, e.g. HKD, USD, CNY
+	LotSize  int    // board lot, 0 if unknown
+
+	// From the HKEX list of securities only
+	Category    string // e.g. Equity
+	SubCategory string // e.g. Equity Securities (Main Board)
+	ParValue    string // e.g. USD 0.50
+	ISIN        string
+}
+
+// codeRange is a range of the HKEX stock code allocation
+type codeRange struct {
+	from, to int
+	secType  SecurityType
+	currency string
+}
+
+// codeRanges follows the HKEX stock code allocation plan, checked in order
+var codeRanges = []codeRange{
+	{2800, 2849, ETF, "HKD"},
+	{3000, 3199, ETF, "HKD"},
+	{4000, 4599, Debt, "HKD"},
+	{5000, 6029, Debt, "HKD"},
+	{7200, 7399, ETF, "HKD"}, // leveraged and inverse products
+	{7500, 7599, ETF, "HKD"},
+	{9000, 9199, ETF, "USD"},
+	{9200, 9399, ETF, "USD"},
+	{9500, 9599, ETF, "USD"},
+	{9800, 9849, ETF, "USD"},
+	{1, 9999, Equity, "HKD"},
+	{10000, 29999, Warrant, "HKD"},
+	{47000, 48999, Warrant, "HKD"}, // inline warrants
+	{50000, 69999, CBBC, "HKD"},
+	{82800, 82849, ETF, "CNY"},
+	{83000, 83199, ETF, "CNY"},
+	{84000, 84599, Debt, "CNY"},
+	{85000, 85999, Debt, "CNY"},
+	{87000, 87099, REIT, "CNY"},
+	{80000, 89999, Equity, "CNY"},
+}
+
+// Classify derives the type, board and currency of a security from its code and name
+func Classify(code int, name string) Security {
+	s := Security{
+		Code:     code,
+		CodeF:    fmt.Sprintf("%05d", code),
+		Name:     name,
+		Type:     Other,
+		Board:    MainBoard,
+		Currency: "HKD",
+	}
+	for _, r := range codeRanges {
+		if code >= r.from && code <= r.to {
+			s.Type = r.secType
+			s.Currency = r.currency
+			break
+		}
+	}
+	if code >= 8000 && code <= 8999 {
+		s.Board = GEM
+	}
+	if s.Type == Equity && strings.Contains(strings.ToUpper(name), "REIT") {
+		s.Type = REIT
+	}
+	return s
+}
+
+// ListOption sets the filters of GetCompanyList
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	types      map[SecurityType]bool // nil for all types
+	boards     map[Board]bool        // nil for all boards
+	currencies map[string]bool       // nil for all currencies
+}
+
+// defaultListOptions keeps the main board equities, ETFs, REITs and debt securities trading in HKD or USD,
+// the same codes as the original 4 digit non-GEM company list
+func defaultListOptions() listOptions {
+	return listOptions{
+		types:      map[SecurityType]bool{Equity: true, ETF: true, REIT: true, Debt: true},
+		boards:     map[Board]bool{MainBoard: true},
+		currencies: map[string]bool{"HKD": true, "USD": true},
+	}
+}
+
+// EquitiesOnly keeps the ordinary equities only
+func EquitiesOnly() ListOption {
+	return func(o *listOptions) {
+		o.types = map[SecurityType]bool{Equity: true}
+	}
+}
+
+// WithTypes keeps the securities of the types
+func WithTypes(types ...SecurityType) ListOption {
+	return func(o *listOptions) {
+		o.types = make(map[SecurityType]bool)
+		for _, t := range types {
+			o.types[t] = true
+		}
+	}
+}
+
+// IncludeGEM includes the GEM board
+func IncludeGEM() ListOption {
+	return func(o *listOptions) {
+		if o.boards != nil {
+			o.boards[GEM] = true
+		}
+	}
+}
+
+// WithCurrencies keeps the securities trading in the currencies
+func WithCurrencies(currencies ...string) ListOption {
+	return func(o *listOptions) {
+		o.currencies = make(map[string]bool)
+		for _, c := range currencies {
+			o.currencies[c] = true
+		}
+	}
+}
+
+// AllSecurities removes all the filters
+func AllSecurities() ListOption {
+	return func(o *listOptions) {
+		o.types = nil
+		o.boards = nil
+		o.currencies = nil
+	}
+}
+
+// match checks if the security passes the filters
+func (o listOptions) match(s Security) bool {
+	if o.types != nil && !o.types[s.Type] {
+		return false
+	}
+	if o.boards != nil && !o.boards[s.Board] {
+		return false
+	}
+	if o.currencies != nil && !o.currencies[s.Currency] {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package stock
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		secName  string
+		wantType SecurityType
+		board    Board
+		currency string
+	}{
+		{"equity", 5, "HSBC HOLDINGS", Equity, MainBoard, "HKD"},
+		{"reit", 823, "LINK REIT", REIT, MainBoard, "HKD"},
+		{"etf", 2800, "TRACKER FUND", ETF, MainBoard, "HKD"},
+		{"usd etf", 9846, "ISHARES A50", ETF, MainBoard, "USD"},
+		{"gem", 8083, "CHINA YOUZAN", Equity, GEM, "HKD"},
+		{"debt", 4246, "HKGB 2409", Debt, MainBoard, "HKD"},
+		{"warrant", 12345, "HS#HSBC RC2106A", Warrant, MainBoard, "HKD"},
+		{"cbbc", 55555, "BI#HSI RP2106Y", CBBC, MainBoard, "HKD"},
+		{"rmb counter", 80737, "SHENZHEN EXPRESSWAY-R", Equity, MainBoard, "CNY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.code, tt.secName)
+			if got.Type != tt.wantType || got.Board != tt.board || got.Currency != tt.currency {
+				t.Errorf("Classify() = %v, want %v %v %v", got, tt.wantType, tt.board, tt.currency)
+			}
+		})
+	}
+}
+
+func TestListOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ListOption
+		code int
+		want bool
+	}{
+		{"default equity", nil, 5, true},
+		{"default debt", nil, 4001, true},
+		{"default gem", nil, 8083, false},
+		{"default warrant", nil, 12345, false},
+		{"default rmb counter", nil, 80737, false},
+		{"without debt", []ListOption{WithTypes(Equity, ETF, REIT)}, 4001, false},
+		{"equities only", []ListOption{EquitiesOnly()}, 2800, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := defaultListOptions()
+			for _, opt := range tt.opts {
+				opt(&options)
+			}
+			if got := options.match(Classify(tt.code, "")); got != tt.want {
+				t.Errorf("listOptions.match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
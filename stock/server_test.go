@@ -0,0 +1,78 @@
+package stock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+// recordedPages maps the request paths, with the query if the page depends on it, to the recorded pages in testdata
+var recordedPages = map[string]string{
+	"/sdw/search/stocklist.aspx":                                                               "testdata/stocklist.html",
+	"/sdw/search/stocklist_c.aspx":                                                             "testdata/stocklist_c.html",
+	"/sdw/search/mutualmarket.aspx":                                                            "testdata/mutualmarket.html",
+	"/eng/csm/DailyStat/data_tab_daily_20210226e.js":                                           "testdata/data_tab_daily_20210226e.js",
+	"/eng/stat/smstat/ssturnover/ncms/MSHTMAIN.HTM":                                            "testdata/MSHTMAIN.HTM",
+	"/eng/stat/smstat/ssturnover/ncms/ASHTMAIN.HTM":                                            "testdata/ASHTMAIN.HTM",
+	"/market-store/board_meeting/earnings_forecasts_bycode.html":                               "testdata/earnings_forecasts_bycode.html",
+	"/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=4&s=&o=&p=": "testdata/industry_ratio.html",
+	"/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=2&s=&o=&p=": "testdata/industry_range.html",
+	"/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=6&s=&o=&p=": "testdata/industry_earnings.html",
+	"/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=4010&t=5&s=&o=&p=": "testdata/industry_banking.html",
+	"/en/stocks/market/industry/sector-industry-details.aspx?industrysymbol=2010&t=5&s=&o=&p=": "testdata/industry_banking_blank.html",
+	"/v8/finance/chart/0005.HK":                                                                "testdata/yahoo_0005.json",
+	"/en/stocks/analysis/company-fundamental/company-profile?symbol=00005":                     "testdata/company_profile_00005.html",
+	"/sdw/search/searchsdw.aspx":                                                               "testdata/searchsdw.html",
+	"/search/titleSearchServlet.do":                                                            "testdata/titleSearchServlet.json",
+	"/search/prefix.do":                                                                        "testdata/prefix.js",
+}
+
+// testServer records the forms posted to the recorded pages
+type testServer struct {
+	sync.Mutex
+	posted map[string]url.Values // by path
+}
+
+// form gets the last form posted to the path
+func (s *testServer) form(path string) url.Values {
+	s.Lock()
+	defer s.Unlock()
+	return s.posted[path]
+}
+
+// newTestServer serves the recorded pages and points the scrapers to it until the test ends
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	ts := &testServer{posted: make(map[string]url.Values)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.ParseForm() == nil {
+			ts.Lock()
+			ts.posted[r.URL.Path] = r.PostForm
+			ts.Unlock()
+		}
+		file, ok := recordedPages[r.URL.RequestURI()]
+		if !ok {
+			file, ok = recordedPages[r.URL.Path]
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, file)
+	}))
+
+	c := web.New()
+	c.SetBaseURL("", server.URL)
+	SetClient(c)
+
+	t.Cleanup(func() {
+		SetClient(web.New())
+		server.Close()
+	})
+	return ts
+}
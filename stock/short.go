@@ -0,0 +1,171 @@
+package stock
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/billylkc/stocklib/util"
+	"github.com/billylkc/stocklib/web"
+	"github.com/lib/pq"
+)
+
+var (
+	// e.g. `DATE: 26 FEB 2021`
+	shortDateRegex = regexp.MustCompile(`(\d{1,2}\s+[A-Za-z]{3}\s+\d{4})`)
+
+	// e.g. `     1  CKH HOLDINGS               1,025,500         61,284,110`
+	shortRegex = regexp.MustCompile(`^[\s%#\*]*(\d{1,5})\s+(.+?)\s+([\d,]+)\s+([\d,]+)\s*$`)
+)
+
+// ShortSelling is the short selling turnover of a stock
+type ShortSelling struct {
+	Date     string
+	Code     string // code in 5 digit format, e.g. 00005
+	Name     string
+	Session  string // AM for morning, DAY for full day
+	Volume   int    // no of shares
+	Turnover int
+}
+
+// GetShortSelling gets the morning and full day short selling turnover from the HKEX reports
+func GetShortSelling(date string) ([]ShortSelling, error) {
+	return GetShortSellingContext(context.Background(), date)
+}
+
+// GetShortSellingContext gets the morning and full day short selling turnover from the HKEX reports
+func GetShortSellingContext(ctx context.Context, date string) ([]ShortSelling, error) {
+	var results []ShortSelling
+
+	reports := []struct {
+		session string
+		path    string
+	}{
+		{"AM", "/eng/stat/smstat/ssturnover/ncms/MSHTMAIN.HTM"},
+		{"DAY", "/eng/stat/smstat/ssturnover/ncms/ASHTMAIN.HTM"},
+	}
+	for _, r := range reports {
+		rec, err := getShortSelling(ctx, date, r.session, client.URL(web.HKEX, r.path))
+		if err != nil {
+			return results, err
+		}
+		results = append(results, rec...)
+	}
+	return results, nil
+}
+
+// InsertShortSelling inserts to the short_selling table
+func InsertShortSelling(data []ShortSelling) error {
+	return InsertShortSellingContext(context.Background(), data)
+}
+
+// InsertShortSellingContext inserts to the short_selling table
+func InsertShortSellingContext(ctx context.Context, data []ShortSelling) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("short_selling", "date", "code", "name", "session", "volume", "turnover"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Code, model.Name, model.Session, model.Volume, model.Turnover)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// getShortSelling gets a single short selling report, only the latest report is published
+func getShortSelling(ctx context.Context, date, session, link string) ([]ShortSelling, error) {
+	var results []ShortSelling
+
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return results, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return results, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	}
+
+	var dated bool
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check the report date before reading the records
+		if !dated {
+			if m := shortDateRegex.FindStringSubmatch(line); m != nil {
+				d, err := time.Parse("2 Jan 2006", strings.Join(strings.Fields(m[1]), " "))
+				if err != nil {
+					return results, err
+				}
+				if d.Format("2006-01-02") != date {
+					return results, fmt.Errorf("data not ready - %s", date)
+				}
+				dated = true
+			}
+			continue
+		}
+
+		if m := shortRegex.FindStringSubmatch(line); m != nil {
+			code, err := util.ParseN(m[1])
+			if err != nil {
+				continue
+			}
+			volume, _ := util.ParseN(m[3])
+			turnover, _ := util.ParseN(m[4])
+
+			rec := ShortSelling{
+				Date:     date,
+				Code:     fmt.Sprintf("%05d", code),
+				Name:     m[2],
+				Session:  session,
+				Volume:   volume,
+				Turnover: turnover,
+			}
+			results = append(results, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return results, err
+	}
+	if !dated {
+		return results, fmt.Errorf("data not ready - %s", date)
+	}
+	return results, nil
+}
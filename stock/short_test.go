@@ -0,0 +1,34 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetShortSelling(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetShortSelling("2021-02-26")
+	if err != nil {
+		t.Fatalf("GetShortSelling() error = %v", err)
+	}
+	want := []ShortSelling{
+		{Date: "2021-02-26", Code: "00001", Name: "CKH HOLDINGS", Session: "AM", Volume: 1025500, Turnover: 61284110},
+		{Date: "2021-02-26", Code: "00005", Name: "HSBC HOLDINGS", Session: "AM", Volume: 1605200, Turnover: 71511177},
+		{Date: "2021-02-26", Code: "00001", Name: "CKH HOLDINGS", Session: "DAY", Volume: 1025500, Turnover: 61284110},
+		{Date: "2021-02-26", Code: "00005", Name: "HSBC HOLDINGS", Session: "DAY", Volume: 3210400, Turnover: 143022354},
+		{Date: "2021-02-26", Code: "00700", Name: "TENCENT", Session: "DAY", Volume: 2001300, Turnover: 1534996700},
+		{Date: "2021-02-26", Code: "02800", Name: "TRACKER FUND", Session: "DAY", Volume: 12345000, Turnover: 370350000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetShortSelling() = %v, want %v", got, want)
+	}
+}
+
+func TestGetShortSelling_NotReady(t *testing.T) {
+	newTestServer(t)
+
+	if _, err := GetShortSelling("2021-03-01"); err == nil {
+		t.Errorf("GetShortSelling() error = nil, want data not ready")
+	}
+}
@@ -0,0 +1 @@
+tabData = [{"id":1,"market":"SSE Northbound","date":"2021-02-26","content":[{"style":1,"table":{"classname":"","schema":[["Total Turnover","Buy Turnover","Sell Turnover","Total Trade Count","Buy Trade Count","Sell Trade Count","Daily Quota Balance","Daily Quota Balance (%)"]],"tr":[{"td":[["60,123.45"]]},{"td":[["28,456.78"]]},{"td":[["31,666.67"]]},{"td":[["3,456,789"]]},{"td":[["1,623,456"]]},{"td":[["1,833,333"]]},{"td":[["54,789.12"]]},{"td":[["105%"]]}]}},{"style":2,"table":{"classname":"","schema":[["Rank","Stock Code","Stock Name","Buy Turnover","Sell Turnover","Total Turnover"]],"tr":[{"td":[["1","600519","KWEICHOW MOUTAI","1,234,567,890","987,654,321","2,222,222,211"]]},{"td":[["2","601318","PING AN","654,321,000","543,210,000","1,197,531,000"]]}]}}]},{"id":2,"market":"SSE Southbound","date":"2021-02-26","content":[{"style":1,"table":{"classname":"","schema":[["Total Turnover","Buy Turnover","Sell Turnover","Total Trade Count","Buy Trade Count","Sell Trade Count"]],"tr":[{"td":[["30,012.34"]]},{"td":[["17,345.67"]]},{"td":[["12,666.67"]]},{"td":[["456,789"]]},{"td":[["256,789"]]},{"td":[["200,000"]]}]}},{"style":2,"table":{"classname":"","schema":[["Rank","Stock Code","Stock Name","Buy Turnover","Sell Turnover","Total Turnover"]],"tr":[{"td":[["1","00700","TENCENT","3,456,789,012","2,345,678,901","5,802,467,913"]]}]}}]}];
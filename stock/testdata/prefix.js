@@ -0,0 +1 @@
+callback({"more":"0","stockInfo":[{"stockId":1000523,"code":"00050","name":"HK FERRY (HOLD)"},{"stockId":7609,"code":"00005","name":"HSBC HOLDINGS"}]});
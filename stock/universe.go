@@ -0,0 +1,321 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/billylkc/stocklib/db"
+	"github.com/lib/pq"
+)
+
+// Security event types
+const (
+	EventNewListing = "NewListing"
+	EventDelisting  = "Delisting"
+	EventCodeChange = "CodeChange"
+	EventNameChange = "NameChange"
+)
+
+// SecurityEvent is a change in the universe between two snapshots
+type SecurityEvent struct {
+	Date    string
+	Type    string // NewListing, Delisting, CodeChange or NameChange
+	Code    string
+	OldCode string // for code change only
+	Name    string
+	OldName string // for code change and name change only
+}
+
+// GetSecurityEvents gets the current universe and diffs it against the latest snapshot before the date.
+// The list of securities is only published for today, so the date must be today in Hong Kong.
+func GetSecurityEvents(date string) ([]Security, []SecurityEvent, error) {
+	return GetSecurityEventsContext(context.Background(), date)
+}
+
+// GetSecurityEventsContext gets the current universe and diffs it against the latest snapshot before the date,
+// which must be today in Hong Kong
+func GetSecurityEventsContext(ctx context.Context, date string) ([]Security, []SecurityEvent, error) {
+	var (
+		curr   []Security
+		events []SecurityEvent
+	)
+
+	if today := hkToday(); date != today {
+		return curr, events, fmt.Errorf("only the current universe is available, date must be today %s - %s", today, date)
+	}
+
+	curr, err := GetSecuritiesContext(ctx)
+	if err != nil {
+		return curr, events, err
+	}
+
+	prev, err := getSnapshot(ctx, date, "<") // strictly before, as the snapshot of the date may be inserted already
+	if err != nil {
+		return curr, events, err
+	}
+	if len(prev) == 0 {
+		return curr, events, nil // first snapshot, nothing to compare
+	}
+	return curr, DiffSnapshots(date, prev, curr), nil
+}
+
+// DiffSnapshots detects the new listings, delistings, code changes and name changes between two snapshots.
+// A removed code and an added code with the same ISIN, or the same name if ISIN is unknown, is a code change.
+func DiffSnapshots(date string, prev, curr []Security) []SecurityEvent {
+	var events []SecurityEvent
+
+	before := make(map[string]Security)
+	for _, s := range prev {
+		before[s.CodeF] = s
+	}
+	after := make(map[string]Security)
+	for _, s := range curr {
+		after[s.CodeF] = s
+	}
+
+	var removed, added []Security
+	for _, s := range prev {
+		if _, ok := after[s.CodeF]; !ok {
+			removed = append(removed, s)
+		}
+	}
+	for _, s := range curr {
+		old, ok := before[s.CodeF]
+		if !ok {
+			added = append(added, s)
+			continue
+		}
+		if old.Name != s.Name {
+			events = append(events, SecurityEvent{Date: date, Type: EventNameChange, Code: s.CodeF, Name: s.Name, OldName: old.Name})
+		}
+	}
+
+	matched := make(map[string]bool) // removed codes matched to an added code
+	for _, s := range added {
+		var found *Security
+		for i, r := range removed {
+			if matched[r.CodeF] {
+				continue
+			}
+			if (s.ISIN != "" && s.ISIN == r.ISIN) || (s.ISIN == "" && s.Name == r.Name) {
+				found = &removed[i]
+				break
+			}
+		}
+		if found != nil {
+			matched[found.CodeF] = true
+			events = append(events, SecurityEvent{Date: date, Type: EventCodeChange, Code: s.CodeF, OldCode: found.CodeF, Name: s.Name, OldName: found.Name})
+			continue
+		}
+		events = append(events, SecurityEvent{Date: date, Type: EventNewListing, Code: s.CodeF, Name: s.Name})
+	}
+	for _, r := range removed {
+		if !matched[r.CodeF] {
+			events = append(events, SecurityEvent{Date: date, Type: EventDelisting, Code: r.CodeF, Name: r.Name})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Code < events[j].Code
+	})
+	return events
+}
+
+// GetSnapshot gets the latest universe snapshot on or before the date, empty if none
+func GetSnapshot(date string) ([]Security, error) {
+	return GetSnapshotContext(context.Background(), date)
+}
+
+// GetSnapshotContext gets the latest universe snapshot on or before the date, empty if none
+func GetSnapshotContext(ctx context.Context, date string) ([]Security, error) {
+	return getSnapshot(ctx, date, "<=")
+}
+
+// getSnapshot gets the latest universe snapshot compared to the date, e.g. "<" for strictly before
+func getSnapshot(ctx context.Context, date, op string) ([]Security, error) {
+	var result []Security
+	database, err := db.GetConnection()
+	if err != nil {
+		return result, err
+	}
+
+	queryF := `
+    SELECT
+       code, name, type, board, currency, COALESCE(lotsize, 0), COALESCE(isin, '')
+    FROM
+       universe_snapshot
+    WHERE
+       date = (SELECT max(date) FROM universe_snapshot WHERE date %s '%s')
+    ORDER BY
+       code;
+    `
+	query := fmt.Sprintf(queryF, op, date)
+	rows, err := database.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			s       Security
+			secType string
+			board   string
+		)
+		if err := rows.Scan(&s.CodeF, &s.Name, &secType, &board, &s.Currency, &s.LotSize, &s.ISIN); err != nil {
+			return result, err
+		}
+		fmt.Sscanf(s.CodeF, "%d", &s.Code)
+		s.Type = SecurityType(secType)
+		s.Board = Board(board)
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// hkToday gets today's date in Hong Kong, in YYYY-MM-DD format
+func hkToday() string {
+	loc, _ := time.LoadLocation("Asia/Hong_Kong")
+	if loc == nil {
+		loc = time.FixedZone("HKT", 8*60*60)
+	}
+	return time.Now().In(loc).Format("2006-01-02")
+}
+
+// GetUniverse gets the codes actually trading on the date from the snapshots, with the same filters as GetCompanyList
+func GetUniverse(date string, opts ...ListOption) ([]int, error) {
+	return GetUniverseContext(context.Background(), date, opts...)
+}
+
+// GetUniverseContext gets the codes actually trading on the date from the snapshots, with the same filters as GetCompanyList
+func GetUniverseContext(ctx context.Context, date string, opts ...ListOption) ([]int, error) {
+	var result []int
+
+	options := defaultListOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	securities, err := GetSnapshotContext(ctx, date)
+	if err != nil {
+		return result, err
+	}
+	for _, s := range securities {
+		if options.match(s) {
+			result = append(result, s.Code)
+		}
+	}
+	if len(result) == 0 {
+		return result, fmt.Errorf("no universe snapshot - %s", date)
+	}
+	return result, nil
+}
+
+// InsertSnapshot inserts the universe on a date to the universe_snapshot table
+func InsertSnapshot(date string, data []Security) error {
+	return InsertSnapshotContext(context.Background(), date, data)
+}
+
+// InsertSnapshotContext inserts the universe on a date to the universe_snapshot table
+func InsertSnapshotContext(ctx context.Context, date string, data []Security) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return err
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("universe_snapshot", "date", "code", "name", "type", "board", "currency", "lotsize", "isin"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, date, model.CodeF, model.Name, string(model.Type), string(model.Board), model.Currency, model.LotSize, model.ISIN)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
+
+// InsertSecurityEvent inserts to the security_event table
+func InsertSecurityEvent(data []SecurityEvent) error {
+	return InsertSecurityEventContext(context.Background(), data)
+}
+
+// InsertSecurityEventContext inserts to the security_event table
+func InsertSecurityEventContext(ctx context.Context, data []SecurityEvent) error {
+
+	if len(data) == 0 {
+		return errors.New("no records to be inserted")
+	}
+	fmt.Printf("Start inserting records - %d\n", len(data))
+
+	db, err := db.GetConnection()
+	if err != nil {
+		return err
+	}
+
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txn.Commit()
+
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("security_event", "date", "type", "code", "oldcode", "name", "oldname"))
+	if err != nil {
+		return (err)
+	}
+
+	for _, model := range data {
+		_, err := stmt.ExecContext(ctx, model.Date, model.Type, model.Code, model.OldCode, model.Name, model.OldName)
+		if err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done")
+	return nil
+}
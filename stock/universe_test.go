@@ -0,0 +1,40 @@
+package stock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	prev := []Security{
+		{CodeF: "00001", Name: "CKH HOLDINGS", ISIN: "KYG217651051"},
+		{CodeF: "00002", Name: "CLP HOLDINGS", ISIN: "HK0002007356"},
+		{CodeF: "00003", Name: "HK & CHINA GAS", ISIN: "HK0003000038"},
+		{CodeF: "00004", Name: "WHARF HOLDINGS"},
+	}
+	curr := []Security{
+		{CodeF: "00001", Name: "CK HUTCHISON", ISIN: "KYG217651051"},
+		{CodeF: "00002", Name: "CLP HOLDINGS", ISIN: "HK0002007356"},
+		{CodeF: "00013", Name: "HK & CHINA GAS", ISIN: "HK0003000038"},
+		{CodeF: "09988", Name: "BABA-SW", ISIN: "KYG017191142"},
+	}
+
+	want := []SecurityEvent{
+		{Date: "2021-03-01", Type: EventNameChange, Code: "00001", Name: "CK HUTCHISON", OldName: "CKH HOLDINGS"},
+		{Date: "2021-03-01", Type: EventDelisting, Code: "00004", Name: "WHARF HOLDINGS"},
+		{Date: "2021-03-01", Type: EventCodeChange, Code: "00013", OldCode: "00003", Name: "HK & CHINA GAS", OldName: "HK & CHINA GAS"},
+		{Date: "2021-03-01", Type: EventNewListing, Code: "09988", Name: "BABA-SW"},
+	}
+	got := DiffSnapshots("2021-03-01", prev, curr)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSnapshots() = %v, want %v", got, want)
+	}
+}
+
+func TestGetSecurityEvents_NotToday(t *testing.T) {
+	newTestServer(t)
+
+	if _, _, err := GetSecurityEvents("2021-02-26"); err == nil {
+		t.Errorf("GetSecurityEvents() error = nil, want the past date rejected")
+	}
+}
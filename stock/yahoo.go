@@ -1,9 +1,155 @@
 package stock
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"time"
+
+	"github.com/billylkc/stocklib/web"
 )
 
-func Yahoo() {
-	fmt.Println("yahoo")
+// YahooPrice is a daily bar from the yahoo finance chart history
+type YahooPrice struct {
+	Code     int
+	CodeF    string // code in string format, e.g. 00005
+	Symbol   string // yahoo symbol, e.g. 0005.HK
+	Date     string
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	AdjClose float64 // adjusted for splits and dividends
+	Volume   int
+}
+
+// yahooChart is the response of the yahoo chart api
+type yahooChart struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Symbol    string `json:"symbol"`
+				GMTOffset int64  `json:"gmtoffset"` // exchange offset in seconds, e.g. 28800 for HKT
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Open   []*float64 `json:"open"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
+					Close  []*float64 `json:"close"`
+					Volume []*int64   `json:"volume"`
+				} `json:"quote"`
+				AdjClose []struct {
+					AdjClose []*float64 `json:"adjclose"`
+				} `json:"adjclose"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// GetYahooPrice gets the daily bars of a single stock between start and end date (inclusive)
+func GetYahooPrice(code int, start, end string) ([]YahooPrice, error) {
+	return GetYahooPriceContext(context.Background(), code, start, end)
+}
+
+// GetYahooPriceContext gets the daily bars of a single stock between start and end date (inclusive)
+func GetYahooPriceContext(ctx context.Context, code int, start, end string) ([]YahooPrice, error) {
+	var result []YahooPrice
+
+	from, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return result, err
+	}
+	to, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return result, err
+	}
+	to = to.AddDate(0, 0, 1) // period2 is exclusive
+
+	link := client.URL(web.Yahoo, fmt.Sprintf("/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d", YahooSymbol(code), from.Unix(), to.Unix()))
+	res, err := client.GetContext(ctx, link)
+	if err != nil {
+		return result, err
+	}
+	defer res.Body.Close()
+
+	// the chart errors, e.g. Not Found, come with a non 200 status
+	result, err = parseYahoo(code, res.Body)
+	if err != nil && res.StatusCode != 200 {
+		return result, fmt.Errorf("status code error: %d %s - %v", res.StatusCode, res.Status, err)
+	}
+	return result, err
+}
+
+// YahooSymbol maps the HK code to the yahoo symbol, e.g. 5 -> 0005.HK
+func YahooSymbol(code int) string {
+	return fmt.Sprintf("%04d.HK", code)
+}
+
+// parseYahoo parses the yahoo chart json, skipping the null bars on non-trading days.
+// Dates are in the exchange time zone.
+func parseYahoo(code int, r io.Reader) ([]YahooPrice, error) {
+	var result []YahooPrice
+
+	var chart yahooChart
+	if err := json.NewDecoder(r).Decode(&chart); err != nil {
+		return result, errors.New("unable to unmarshal the response")
+	}
+	if e := chart.Chart.Error; e != nil {
+		return result, fmt.Errorf("yahoo error - %s - %s", e.Code, e.Description)
+	}
+	if len(chart.Chart.Result) == 0 || len(chart.Chart.Result[0].Indicators.Quote) == 0 {
+		return result, errors.New("not found")
+	}
+
+	data := chart.Chart.Result[0]
+	quote := data.Indicators.Quote[0]
+	var adjClose []*float64
+	if len(data.Indicators.AdjClose) > 0 {
+		adjClose = data.Indicators.AdjClose[0].AdjClose
+	}
+	loc := time.FixedZone(data.Meta.Symbol, int(data.Meta.GMTOffset))
+
+	for i, ts := range data.Timestamp {
+		open, high, low, close := seriesValue(quote.Open, i), seriesValue(quote.High, i), seriesValue(quote.Low, i), seriesValue(quote.Close, i)
+		if open == nil || high == nil || low == nil || close == nil {
+			continue
+		}
+		rec := YahooPrice{
+			Code:   code,
+			CodeF:  fmt.Sprintf("%05d", code),
+			Symbol: YahooSymbol(code),
+			Date:   time.Unix(ts, 0).In(loc).Format("2006-01-02"),
+			Open:   *open,
+			High:   *high,
+			Low:    *low,
+			Close:  *close,
+		}
+		if adj := seriesValue(adjClose, i); adj != nil {
+			rec.AdjClose = *adj
+		}
+		if i < len(quote.Volume) && quote.Volume[i] != nil {
+			rec.Volume = int(*quote.Volume[i])
+		}
+		result = append(result, rec)
+	}
+	if len(result) == 0 {
+		return result, errors.New("not found")
+	}
+	return result, nil
+}
+
+// seriesValue gets the i-th value of a series, nil if missing or null
+func seriesValue(series []*float64, i int) *float64 {
+	if i >= len(series) {
+		return nil
+	}
+	return series[i]
 }
@@ -0,0 +1,68 @@
+package stock
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestYahooSymbol(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		want string
+	}{
+		{"single digit", 5, "0005.HK"},
+		{"four digit", 2800, "2800.HK"},
+		{"five digit", 80737, "80737.HK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := YahooSymbol(tt.code); got != tt.want {
+				t.Errorf("YahooSymbol() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetYahooPrice(t *testing.T) {
+	newTestServer(t)
+
+	got, err := GetYahooPrice(5, "2021-02-22", "2021-02-26")
+	if err != nil {
+		t.Fatalf("GetYahooPrice() error = %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("GetYahooPrice() got %d records, want 4", len(got))
+	}
+	want := YahooPrice{
+		Code:     5,
+		CodeF:    "00005",
+		Symbol:   "0005.HK",
+		Date:     "2021-02-26",
+		Open:     45.049999,
+		High:     45.5,
+		Low:      44.049999,
+		Close:    44.400002,
+		AdjClose: 42.954292,
+		Volume:   40112392,
+	}
+	if !reflect.DeepEqual(got[3], want) {
+		t.Errorf("GetYahooPrice() = %v, want %v", got[3], want)
+	}
+	if got[2].Date != "2021-02-25" {
+		t.Errorf("GetYahooPrice() date = %v, want the null bar on 2021-02-24 skipped", got[2].Date)
+	}
+}
+
+func Test_parseYahoo_Error(t *testing.T) {
+	f, err := os.Open("testdata/yahoo_9999.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := parseYahoo(9999, f); err == nil {
+		t.Errorf("parseYahoo() error = nil, want the chart error")
+	}
+}
@@ -1,14 +1,16 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/billylkc/stocklib/web"
 )
 
 // PrettyPrint to print struct in a readable way
@@ -87,28 +89,45 @@ func ParseI(s string) (int, error) {
 	return num, nil
 }
 
+// ParseN parses from string to integer, ignoring the thousand separators, e.g. 1,234,567
+func ParseN(s string) (int, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	num, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return num, nil
+}
+
 // CheckWebsiteDate checks the date from the aastock page and see if it matches the input date
-func CheckWebsiteDate(date string) bool {
-	res, err := http.Get("http://www.aastocks.com/en/stocks/market/industry/industry-performance.aspx")
+func CheckWebsiteDate(date string) (bool, error) {
+	return CheckWebsiteDateContext(context.Background(), web.New(), date)
+}
+
+// CheckWebsiteDateContext checks the date on the aastock page with the caller's http client, e.g. the one of the scrapers
+func CheckWebsiteDateContext(ctx context.Context, client *web.Client, date string) (bool, error) {
+	res, err := client.GetContext(ctx, client.URL(web.AAStocks, "/en/stocks/market/industry/industry-performance.aspx"))
 	if err != nil {
-		log.Fatal(err)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		log.Fatalf("status code error: %d %s", res.StatusCode, res.Status)
+		return false, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
 	}
 	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
 	re := regexp.MustCompile(`.*Last Update:\s*(\d{4}\/\d{2}\/\d{2})`)
 	matched := re.FindAllSubmatch(body, -1)
+	if len(matched) == 0 {
+		return false, errors.New("last update date not found on the page")
+	}
 
-	// TODO: better checking later
-	var b bool
 	web := string(matched[0][1]) // date on website, e.g. 2021/02/26
 	web = strings.ReplaceAll(web, "/", "-")
-	if date == web {
-		b = true
-	} else {
-		b = false
-	}
-	return b
+	return date == web, nil
 }
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/billylkc/stocklib/web"
+)
+
+func TestCheckWebsiteDateContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		date    string
+		want    bool
+		wantErr bool
+	}{
+		{"ready", "<span>Last Update: 2021/02/26 16:10</span>", "2021-02-26", true, false},
+		{"not ready", "<span>Last Update: 2021/02/25 16:10</span>", "2021-02-26", false, false},
+		{"no date", "<html>Service Unavailable</html>", "2021-02-26", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.page)
+			}))
+			defer server.Close()
+			c := web.New()
+			c.SetBaseURL(web.AAStocks, server.URL)
+
+			got, err := CheckWebsiteDateContext(context.Background(), c, tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckWebsiteDateContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CheckWebsiteDateContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
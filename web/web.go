@@ -0,0 +1,121 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Sites of the base urls
+const (
+	AAStocks       = "aastocks"        // aastocks industry pages
+	HKEX           = "hkex"            // hkex reports and statistics
+	HKEXNews       = "hkexnews"        // hkexnews CCASS stock list
+	HKEXNewsSDW    = "hkexnews_sdw"    // hkexnews shareholding disclosure search
+	HKEXNewsSearch = "hkexnews_search" // hkexnews title search and documents
+	HKET           = "hket"            // hket earnings forecasts
+	Yahoo          = "yahoo"           // yahoo finance history
+	Quandl         = "quandl"          // nasdaq data link, formerly quandl
+	HKMA           = "hkma"            // hkma open api
+)
+
+// defaultBaseURLs of the sites
+var defaultBaseURLs = map[string]string{
+	AAStocks:       "http://www.aastocks.com",
+	HKEX:           "https://www.hkex.com.hk",
+	HKEXNews:       "https://www.hkexnews.hk",
+	HKEXNewsSDW:    "https://www3.hkexnews.hk",
+	HKEXNewsSearch: "https://www1.hkexnews.hk",
+	HKET:           "https://invest.hket.com",
+	Yahoo:          "https://query1.finance.yahoo.com",
+	Quandl:         "https://data.nasdaq.com/api/v3",
+	HKMA:           "https://api.hkma.gov.hk",
+}
+
+// Client is the shared http client of the scrapers, with the base urls, user agent and headers
+type Client struct {
+	HTTP      *http.Client
+	BaseURLs  map[string]string // by site, e.g. aastocks -> http://www.aastocks.com
+	UserAgent string
+	Header    http.Header // added to every request
+}
+
+// New as Client constructor, with the default base urls
+func New() *Client {
+	baseURLs := make(map[string]string)
+	for site, base := range defaultBaseURLs {
+		baseURLs[site] = base
+	}
+	return &Client{
+		HTTP:      &http.Client{Timeout: 60 * time.Second},
+		BaseURLs:  baseURLs,
+		UserAgent: "Mozilla/5.0", // default go agent is rejected by some sites
+		Header:    make(http.Header),
+	}
+}
+
+// SetBaseURL sets the base url of a site, or all the sites if site is empty, e.g. to a httptest.Server
+func (c *Client) SetBaseURL(site, base string) {
+	base = strings.TrimSuffix(base, "/")
+	if site != "" {
+		c.BaseURLs[site] = base
+		return
+	}
+	for s := range defaultBaseURLs {
+		c.BaseURLs[s] = base
+	}
+}
+
+// URL joins the base url of the site with the path, e.g. /en/stocks/market/industry/industry-performance.aspx
+func (c *Client) URL(site, path string) string {
+	return c.BaseURLs[site] + path
+}
+
+// Get issues a GET with the user agent and headers
+func (c *Client) Get(link string) (*http.Response, error) {
+	return c.GetContext(context.Background(), link)
+}
+
+// GetContext issues a GET with the context, e.g. for cancellation and deadlines
+func (c *Client) GetContext(ctx context.Context, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// PostForm issues a POST of the form with the user agent and headers
+func (c *Client) PostForm(link string, data url.Values) (*http.Response, error) {
+	return c.PostFormContext(context.Background(), link, data)
+}
+
+// PostFormContext issues a POST of the form with the context
+func (c *Client) PostFormContext(ctx context.Context, link string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", link, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.Do(req)
+}
+
+// Do sends the request with the user agent and headers
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	for k, values := range c.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}